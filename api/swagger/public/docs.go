@@ -20,6 +20,100 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/approvers": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get approved, declined and pending counters and response lags of every approver",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get approvers statistics",
+                "operationId": "approvers",
+                "responses": {
+                    "200": {
+                        "description": "approvers statistics",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ApproverStats"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/approvers/{email}": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get approved, declined and pending counters and response lags of the approver",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get approver statistics",
+                "operationId": "approver",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "approver email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "approver statistics",
+                        "schema": {
+                            "$ref": "#/definitions/models.ApproverStats"
+                        }
+                    },
+                    "404": {
+                        "description": "approver not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/delays": {
             "get": {
                 "security": [
@@ -27,30 +121,597 @@ const docTemplate = `{
                         "Auth": []
                     }
                 ],
-                "description": "Get delays on all finished and declined tasks",
+                "description": "Get delays on finished and declined tasks ordered by task id.\nPass the X-Next-Cursor header value as cursor to get the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get delays",
+                "operationId": "delays",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tasks finished since, RFC3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tasks finished before, RFC3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 100 by default, 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "last task id of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "task id and lag",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Delay"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the next page, absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/delays/stats": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get min, max, mean, median, 90, 95 and 99 percentiles and a histogram of delays\non finished and declined tasks",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get delay statistics",
+                "operationId": "delayStats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "final state of tasks of the transition table, any by default",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tasks finished since, RFC3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tasks finished before, RFC3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of histogram buckets, 10 by default, 100 at most",
+                        "name": "buckets",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "delay statistics",
+                        "schema": {
+                            "$ref": "#/definitions/models.DelayStats"
+                        }
+                    },
+                    "400": {
+                        "description": "bad parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rejected": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get messages that failed parsing or processing and have not been replayed yet, ordered by id.\nPass the X-Next-Cursor header value as cursor to get the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dead-letter"
+                ],
+                "summary": "Get rejected messages",
+                "operationId": "rejected",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 100 by default, 1000 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "last message id of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "rejected messages",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RejectedMessage"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the next page, absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "dead-letter store is not configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/rejected/{id}/replay": {
+            "post": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Process the rejected message once again, on success it is not listed anymore",
+                "tags": [
+                    "dead-letter"
+                ],
+                "summary": "Replay rejected message",
+                "operationId": "replay",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "rejected message id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "message has been processed"
+                    },
+                    "400": {
+                        "description": "bad message id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "message not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "message has already been replayed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "message has been rejected again",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "dead-letter store is not configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/sla/breaches": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get tasks waiting for the approver longer than allowed by the SLA policy, the longest waiting go first.\nWaited and limit are given in nanoseconds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sla"
+                ],
+                "summary": "Get SLA breaches",
+                "operationId": "slaBreaches",
+                "responses": {
+                    "200": {
+                        "description": "overdue tasks",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Breach"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "501": {
+                        "description": "SLA policy is not configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/pending": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get counters of tasks still in CREATED, MESSAGE_SENT or APPROVED state.\nAges are measured from the last event of every task and given in nanoseconds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get pending tasks",
+                "operationId": "pending",
+                "responses": {
+                    "200": {
+                        "description": "pending tasks by state",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PendingState"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get the current state, approver and accumulated lag of the task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get task state",
+                "operationId": "task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "task id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "task state",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskState"
+                        }
+                    },
+                    "400": {
+                        "description": "bad task id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get all events stored for the task in the order they have been received",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "analytics"
                 ],
-                "summary": "Get delays",
-                "operationId": "delays",
+                "summary": "Get task history",
+                "operationId": "history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "task id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "task id and lag",
+                        "description": "task events",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Delay"
+                                "$ref": "#/definitions/models.Event"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad task id",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "task not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/timeseries": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get numbers of finished or declined tasks or their average lag bucketed by the time tasks\nhave reached the final state. Buckets start at the beginning of a day, a week (Monday) or a month\nin the tz time zone, buckets without tasks are omitted. avg_lag is given in nanoseconds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get time series",
+                "operationId": "timeseries",
+                "parameters": [
+                    {
+                        "enum": [
+                            "finished",
+                            "declined",
+                            "avg_lag"
+                        ],
+                        "type": "string",
+                        "description": "finished, declined or avg_lag",
+                        "name": "metric",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "day (default), week or month",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone name, UTC by default",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tasks finished since, RFC3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tasks finished before, RFC3339",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "time series",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Point"
                             }
                         }
                     },
+                    "400": {
+                        "description": "bad parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "internal error",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
@@ -83,12 +744,129 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "503": {
+                        "description": "storage is unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/transitions": {
+            "get": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Get the transition table the events are applied with. An empty from state means a new task.\nThe dot format renders the table as a Graphviz digraph.",
+                "produces": [
+                    "application/json",
+                    "text/vnd.graphviz"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get task state machine",
+                "operationId": "transitions",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "dot"
+                        ],
+                        "type": "string",
+                        "description": "json (default) or dot",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "transition table",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Transition"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad format",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "models.ApproverStats": {
+            "type": "object",
+            "properties": {
+                "approved": {
+                    "type": "integer"
+                },
+                "avglag": {
+                    "type": "integer"
+                },
+                "declined": {
+                    "type": "integer"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "medianlag": {
+                    "type": "integer"
+                },
+                "p95lag": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Breach": {
+            "type": "object",
+            "properties": {
+                "approver": {
+                    "type": "string"
+                },
+                "detectedat": {
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "sentat": {
+                    "type": "string"
+                },
+                "taskid": {
+                    "type": "integer"
+                },
+                "waited": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Bucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.Delay": {
             "type": "object",
             "properties": {
@@ -100,6 +878,150 @@ const docTemplate = `{
                 }
             }
         },
+        "models.DelayStats": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "histogram": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Bucket"
+                    }
+                },
+                "max": {
+                    "type": "integer"
+                },
+                "mean": {
+                    "type": "integer"
+                },
+                "median": {
+                    "type": "integer"
+                },
+                "min": {
+                    "type": "integer"
+                },
+                "p90": {
+                    "type": "integer"
+                },
+                "p95": {
+                    "type": "integer"
+                },
+                "p99": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Event": {
+            "type": "object",
+            "properties": {
+                "approver": {
+                    "type": "string"
+                },
+                "delay": {
+                    "type": "integer"
+                },
+                "eventtype": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "recievedat": {
+                    "type": "string"
+                },
+                "taskid": {
+                    "type": "integer"
+                },
+                "totaldelay": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PendingState": {
+            "type": "object",
+            "properties": {
+                "avgage": {
+                    "type": "integer"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "maxage": {
+                    "type": "integer"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Point": {
+            "type": "object",
+            "properties": {
+                "start": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RejectedMessage": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "partition": {
+                    "type": "integer"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "rejectedat": {
+                    "type": "string"
+                },
+                "replayedat": {
+                    "type": "string"
+                },
+                "topic": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TaskState": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "type": "integer"
+                },
+                "approver": {
+                    "type": "string"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "taskid": {
+                    "type": "integer"
+                },
+                "totaldelay": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.Totals": {
             "type": "object",
             "properties": {
@@ -110,6 +1032,23 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "models.Transition": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "guard": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
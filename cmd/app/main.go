@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"flag"
+	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	// the tz parameter of /timeseries needs zone data missing in the alpine image
+	_ "time/tzdata"
 
 	"github.com/seggga/approve-analytics/internal/application"
 )
@@ -12,6 +16,15 @@ import (
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
 	defer cancel()
+
+	flag.Parse()
+	if flag.Arg(0) == "migrate" {
+		if err := application.Migrate(ctx, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	go application.Start(ctx)
 	<-ctx.Done()
 	application.Stop()
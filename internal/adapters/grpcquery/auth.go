@@ -0,0 +1,51 @@
+package grpcquery
+
+import (
+	"context"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadata keys holding tokens, they match cookie names used by the REST server
+const (
+	accessKey  = "access"
+	refreshKey = "refresh"
+)
+
+type ctxKeyUser struct{}
+
+// checkAuth validates tokens passed in metadata, refreshed tokens are sent back in the header
+func (s *Server) checkAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	tokens := &models.TokenPair{}
+	if v := md.Get(accessKey); len(v) != 0 {
+		tokens.Access = v[0]
+	}
+	if v := md.Get(refreshKey); len(v) != 0 {
+		tokens.Refresh = v[0]
+	}
+	if tokens.Access == "" && tokens.Refresh == "" {
+		return nil, status.Error(codes.Unauthenticated, "no token passed")
+	}
+
+	tokenPair, err := s.auth.Authenticate(ctx, tokens)
+	if err != nil {
+		s.logger.Sugar().Debugf("authentication failed for %s: %v", info.FullMethod, err)
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	// we have to pass new tokens to the client
+	if tokenPair.Refreshed {
+		err = grpc.SetHeader(ctx, metadata.Pairs(accessKey, tokenPair.Access, refreshKey, tokenPair.Refresh))
+		if err != nil {
+			s.logger.Sugar().Debugf("error sending refreshed tokens: %v", err)
+		}
+	}
+
+	return handler(context.WithValue(ctx, ctxKeyUser{}, tokenPair.Login), req)
+}
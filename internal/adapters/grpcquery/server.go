@@ -0,0 +1,178 @@
+package grpcquery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/seggga/approve-analytics/internal/adapters/msglistener/goodrpc"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+	pb "github.com/seggga/approve-analytics/pkg/proto/analytics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// stopTimeout limits time given to running calls on graceful stop
+const stopTimeout = 10 * time.Second
+
+var _ pb.AnalyticsQueryServer = &Server{}
+
+// Server is a gRPC server giving access to analytics aggregates
+type Server struct {
+	an     ports.Analyter
+	auth   ports.Auther
+	logger *zap.Logger
+
+	srv      *grpc.Server
+	listener net.Listener
+	pb.UnimplementedAnalyticsQueryServer
+}
+
+// New creates a query server, every call is authenticated by auth
+func New(an ports.Analyter, auth ports.Auther, logger *zap.Logger, port string) *Server {
+	s := newServer(an, auth, logger)
+
+	var err error
+	s.listener, err = net.Listen("tcp", ":"+port)
+	if err != nil {
+		logger.Sugar().Fatalf("error creating listener on port %s: %v", port, err)
+	}
+
+	return s
+}
+
+// newServer creates a server without a listener
+func newServer(an ports.Analyter, auth ports.Auther, logger *zap.Logger) *Server {
+	s := &Server{
+		an:     an,
+		auth:   auth,
+		logger: logger,
+	}
+	s.srv = grpc.NewServer(grpc.UnaryInterceptor(s.checkAuth))
+	pb.RegisterAnalyticsQueryServer(s.srv, s)
+
+	return s
+}
+
+// Start ...
+func (s *Server) Start() error {
+	s.logger.Debug("starting gRPC query server ...")
+
+	// Serve returns nil after Stop or GracefulStop
+	if err := s.srv.Serve(s.listener); err != nil {
+		return fmt.Errorf("cannot start gRPC query server: %v", err)
+	}
+	return nil
+}
+
+// Stop waits for running calls to finish, calls still running after stopTimeout are cancelled
+func (s *Server) Stop() {
+	s.logger.Debug("stopping gRPC query server ...")
+
+	stopped := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(stopTimeout):
+		s.srv.Stop()
+	}
+}
+
+// GetTotals returns numbers of finished and declined tasks
+func (s *Server) GetTotals(ctx context.Context, _ *empty.Empty) (*pb.TotalsResponse, error) {
+	s.logger.Debug("totals requested")
+
+	totals, err := s.an.GetTotals(ctx)
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting totals %v", err)
+		return nil, status.Errorf(goodrpc.ErrorCode(err), "error getting totals: %v", err)
+	}
+
+	return &pb.TotalsResponse{
+		Finished: totals.Finished,
+		Declined: totals.Declined,
+	}, nil
+}
+
+// GetDelays returns a page of delays on finished and declined tasks
+func (s *Server) GetDelays(ctx context.Context, req *pb.DelaysRequest) (*pb.DelaysResponse, error) {
+	s.logger.Debug("delays requested")
+
+	filter := &models.DelayFilter{
+		Cursor: req.GetCursor(),
+		Limit:  req.GetLimit(),
+		Desc:   req.GetDesc(),
+	}
+	if req.GetFrom() != nil {
+		filter.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		filter.To = req.GetTo().AsTime()
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "bad delays request: %v", err)
+	}
+
+	_, delays, err := s.an.GetAggregates(ctx, filter)
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting aggregates %v", err)
+		return nil, status.Errorf(goodrpc.ErrorCode(err), "error getting delays: %v", err)
+	}
+
+	resp := &pb.DelaysResponse{
+		Delays: make([]*pb.Delay, 0, len(delays)),
+	}
+	for _, d := range delays {
+		resp.Delays = append(resp.Delays, &pb.Delay{
+			TaskID: d.ID,
+			Lag:    durationpb.New(d.Lag),
+		})
+	}
+	if uint64(len(delays)) == filter.Limit {
+		resp.NextCursor = delays[len(delays)-1].ID
+	}
+
+	return resp, nil
+}
+
+// GetTaskHistory returns all events stored for the task
+func (s *Server) GetTaskHistory(ctx context.Context, req *pb.TaskHistoryRequest) (*pb.TaskHistoryResponse, error) {
+	s.logger.Sugar().Debugf("history of task %d requested", req.GetTaskID())
+
+	events, err := s.an.GetHistory(ctx, req.GetTaskID())
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting history %v", err)
+		return nil, status.Errorf(goodrpc.ErrorCode(err), "error getting task history: %v", err)
+	}
+	if len(events) == 0 {
+		return nil, status.Errorf(codes.NotFound, "task %d not found", req.GetTaskID())
+	}
+
+	resp := &pb.TaskHistoryResponse{
+		Events: make([]*pb.Event, 0, len(events)),
+	}
+	for _, evt := range events {
+		resp.Events = append(resp.Events, &pb.Event{
+			ID:         evt.ID,
+			EventType:  evt.EventType,
+			TaskID:     evt.TaskID,
+			Approver:   evt.Approver,
+			TimeStamp:  timestamppb.New(evt.RecievedAt),
+			Delay:      durationpb.New(evt.Delay),
+			TotalDelay: durationpb.New(evt.TotalDelay),
+		})
+	}
+
+	return resp, nil
+}
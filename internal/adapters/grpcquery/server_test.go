@@ -0,0 +1,160 @@
+package grpcquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+	pb "github.com/seggga/approve-analytics/pkg/proto/analytics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	validToken   = "valid"
+	expiredToken = "expired"
+)
+
+// analyter returns fixed aggregates, methods not used by the server are left unimplemented
+type analyter struct {
+	ports.Analyter
+}
+
+func (analyter) GetTotals(ctx context.Context) (*models.Totals, error) {
+	return &models.Totals{Finished: 2, Declined: 1}, nil
+}
+
+func (analyter) GetAggregates(ctx context.Context, filter *models.DelayFilter) (*models.Totals, []models.Delay, error) {
+	delays := []models.Delay{{ID: 1, Lag: time.Second}, {ID: 2, Lag: 2 * time.Second}, {ID: 3, Lag: 3 * time.Second}}
+	if filter != nil && filter.Limit < uint64(len(delays)) {
+		delays = delays[:filter.Limit]
+	}
+	return &models.Totals{Finished: 2, Declined: 1}, delays, nil
+}
+
+func (analyter) GetHistory(ctx context.Context, taskID uint64) ([]models.Event, error) {
+	switch taskID {
+	case 3:
+		return nil, fmt.Errorf("%w: connection refused", analytic.ErrStorage)
+	case 4:
+		return nil, fmt.Errorf("error selecting events: %w", context.DeadlineExceeded)
+	}
+	if taskID != 1 {
+		return nil, nil
+	}
+	return []models.Event{{ID: 1, EventType: models.Created, TaskID: 1}}, nil
+}
+
+// auther accepts the valid access token and refreshes tokens by the valid refresh token
+type auther struct{}
+
+func (auther) Authenticate(ctx context.Context, token *models.TokenPair) (*models.TokenPair, error) {
+	if token.Access == validToken {
+		return &models.TokenPair{Login: "user"}, nil
+	}
+	if token.Refresh == validToken {
+		return &models.TokenPair{Access: "new-access", Refresh: "new-refresh", Login: "user", Refreshed: true}, nil
+	}
+	return nil, errors.New("token is not valid")
+}
+
+func newClient(t *testing.T) pb.AnalyticsQueryClient {
+	lis := bufconn.Listen(1024 * 1024)
+	s := newServer(analyter{}, auther{}, zap.NewNop())
+	s.listener = lis
+	go s.Start()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.TODO(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatalf("error creating client connection: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewAnalyticsQueryClient(conn)
+}
+
+func TestAuth(t *testing.T) {
+	client := newClient(t)
+
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		code    codes.Code
+		refresh bool
+	}{
+		{name: "no tokens", md: metadata.MD{}, code: codes.Unauthenticated},
+		{name: "expired tokens", md: metadata.Pairs(accessKey, expiredToken, refreshKey, expiredToken), code: codes.Unauthenticated},
+		{name: "valid access token", md: metadata.Pairs(accessKey, validToken), code: codes.OK},
+		{name: "refreshed tokens", md: metadata.Pairs(accessKey, expiredToken, refreshKey, validToken), code: codes.OK, refresh: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header metadata.MD
+			ctx := metadata.NewOutgoingContext(context.TODO(), tt.md)
+			_, err := client.GetTotals(ctx, &empty.Empty{}, grpc.Header(&header))
+			if status.Code(err) != tt.code {
+				t.Fatalf("expected code %v, got %v", tt.code, err)
+			}
+			if tt.refresh && (len(header.Get(accessKey)) == 0 || header.Get(accessKey)[0] != "new-access") {
+				t.Fatalf("refreshed tokens have not been sent: %v", header)
+			}
+		})
+	}
+}
+
+func TestQueries(t *testing.T) {
+	client := newClient(t)
+	ctx := metadata.NewOutgoingContext(context.TODO(), metadata.Pairs(accessKey, validToken))
+
+	totals, err := client.GetTotals(ctx, &empty.Empty{})
+	if err != nil || totals.Finished != 2 || totals.Declined != 1 {
+		t.Fatalf("wrong totals: %v, %v", totals, err)
+	}
+
+	delays, err := client.GetDelays(ctx, &pb.DelaysRequest{Limit: 2})
+	if err != nil || len(delays.Delays) != 2 || delays.NextCursor != 2 || delays.Delays[1].Lag.AsDuration() != 2*time.Second {
+		t.Fatalf("wrong delays page: %v, %v", delays, err)
+	}
+
+	_, err = client.GetDelays(ctx, &pb.DelaysRequest{Limit: models.MaxLimit + 1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected invalid argument on a big limit, got %v", err)
+	}
+
+	history, err := client.GetTaskHistory(ctx, &pb.TaskHistoryRequest{TaskID: 1})
+	if err != nil || len(history.Events) != 1 || history.Events[0].EventType != models.Created {
+		t.Fatalf("wrong history: %v, %v", history, err)
+	}
+
+	_, err = client.GetTaskHistory(ctx, &pb.TaskHistoryRequest{TaskID: 2})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected not found on unknown task, got %v", err)
+	}
+
+	_, err = client.GetTaskHistory(ctx, &pb.TaskHistoryRequest{TaskID: 3})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected unavailable on storage failure, got %v", err)
+	}
+
+	// errors are mapped like the ones of the message listener
+	_, err = client.GetTaskHistory(ctx, &pb.TaskHistoryRequest{TaskID: 4})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded on storage timeout, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package goodrpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+	"google.golang.org/grpc/codes"
+)
+
+// ErrorCode maps an error returned by analytics to a gRPC status code,
+// both message listener and query servers report errors this way
+func ErrorCode(err error) codes.Code {
+	switch {
+	case err == nil:
+		return codes.OK
+	case errors.Is(err, analytic.ErrInvalidMessage):
+		return codes.InvalidArgument
+	case errors.Is(err, analytic.ErrInvalidTransition):
+		return codes.FailedPrecondition
+	case errors.Is(err, analytic.ErrApproverMismatch):
+		return codes.PermissionDenied
+	case errors.Is(err, analytic.ErrStorage):
+		return codes.Unavailable
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
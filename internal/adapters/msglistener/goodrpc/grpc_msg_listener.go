@@ -4,8 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
-	"net/http"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/seggga/approve-analytics/internal/domain/models"
@@ -13,12 +14,16 @@ import (
 	pb "github.com/seggga/approve-analytics/pkg/proto/analytics"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 )
 
 var (
 	_ ports.MsgListener2 = &Server{}
 )
 
+// stopTimeout limits time given to running calls on graceful stop
+const stopTimeout = 10 * time.Second
+
 // Server is a gRPC server based on pb package
 type Server struct {
 	an     ports.Analyter
@@ -49,42 +54,116 @@ func New(an ports.Analyter, logger *zap.Logger, port string) *Server {
 func (s *Server) Start() error {
 	s.logger.Debug("starting gRPC server ...")
 
-	if err := s.srv.Serve(s.listener); !errors.Is(err, http.ErrServerClosed) {
+	// Serve returns nil after Stop or GracefulStop
+	if err := s.srv.Serve(s.listener); err != nil {
 		return fmt.Errorf("cannot start gRPC server: %v", err)
 	}
 	return nil
 }
 
-// Stop ...
+// Stop waits for running calls to finish, calls still running after stopTimeout are cancelled
 func (s *Server) Stop() {
 	s.logger.Debug("stopping gRPC server ...")
 
-	s.srv.GracefulStop()
+	stopped := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(stopTimeout):
+		s.srv.Stop()
+	}
 	// s.logger.Info("gRPC server stopped")
 }
 
-// WriteMessage ...
+// WriteMessage passes the message to analytics, rejections are reported with a matching status code
 func (s *Server) WriteMessage(ctx context.Context, req *pb.WriteMessageRequest) (*empty.Empty, error) {
 
 	s.logger.Debug("incoming message...")
 
-	msg := &models.Message{
-		EventType:  req.EventType,
-		TaskID:     req.TaskID,
-		Approver:   req.Approver,
-		RecievedAt: req.TimeStamp.AsTime(),
-	}
-
-	s.logger.Sugar().Debugf("message %v", msg)
-
-	err := s.an.WriteEvent(ctx, msg)
+	err := s.writeMessage(ctx, req)
 	if err != nil {
 
 		s.logger.Sugar().Debugf("error writing message: %v", err)
-		return new(empty.Empty), fmt.Errorf("error writing message: %v", req)
+		return new(empty.Empty), status.Errorf(ErrorCode(err), "error writing message: %v", err)
 	}
 
 	s.logger.Debug("message has been written")
 
 	return new(empty.Empty), nil
 }
+
+// WriteMessages processes a batch of messages in the given order.
+// A rejected message does not stop processing of the next ones
+func (s *Server) WriteMessages(ctx context.Context, req *pb.WriteMessagesRequest) (*pb.WriteMessagesResponse, error) {
+
+	s.logger.Sugar().Debugf("incoming batch of %d messages...", len(req.GetMessages()))
+
+	resp := &pb.WriteMessagesResponse{
+		Results: make([]*pb.MessageResult, 0, len(req.GetMessages())),
+	}
+	for i, msgReq := range req.GetMessages() {
+		s.addResult(resp, uint32(i), s.writeMessage(ctx, msgReq))
+	}
+
+	s.logger.Sugar().Debugf("batch has been processed: %d accepted, %d rejected", resp.Accepted, resp.Rejected)
+
+	return resp, nil
+}
+
+// StreamMessages processes messages in the order they are received,
+// results are sent back when the client closes the stream
+func (s *Server) StreamMessages(stream pb.AnalyticAPI_StreamMessagesServer) error {
+
+	s.logger.Debug("incoming stream of messages...")
+
+	resp := &pb.WriteMessagesResponse{}
+	for i := uint32(0); ; i++ {
+		msgReq, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			s.logger.Sugar().Debugf("stream has been processed: %d accepted, %d rejected", resp.Accepted, resp.Rejected)
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			s.logger.Sugar().Debugf("error receiving message: %v", err)
+			return err
+		}
+
+		s.addResult(resp, i, s.writeMessage(stream.Context(), msgReq))
+	}
+}
+
+// writeMessage converts the request to a message and passes it to analytics
+func (s *Server) writeMessage(ctx context.Context, req *pb.WriteMessageRequest) error {
+	msg := &models.Message{
+		ID:         req.GetMessageID(),
+		EventType:  req.GetEventType(),
+		TaskID:     req.GetTaskID(),
+		Approver:   req.GetApprover(),
+		RecievedAt: req.GetTimeStamp().AsTime(),
+	}
+
+	s.logger.Sugar().Debugf("message %v", msg)
+
+	return s.an.WriteEvent(ctx, msg)
+}
+
+// addResult appends the result of the message with index i to the response
+func (s *Server) addResult(resp *pb.WriteMessagesResponse, i uint32, err error) {
+	result := &pb.MessageResult{
+		Index:    i,
+		Accepted: err == nil,
+	}
+	if err != nil {
+		s.logger.Sugar().Debugf("error writing message %d: %v", i, err)
+		result.Error = err.Error()
+		result.Code = uint32(ErrorCode(err))
+		resp.Rejected++
+	} else {
+		resp.Accepted++
+	}
+	resp.Results = append(resp.Results, result)
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/seggga/approve-analytics/internal/domain/models"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	pb "github.com/seggga/approve-analytics/pkg/proto/analytics"
@@ -122,8 +124,15 @@ var (
 )
 
 func TestMain(m *testing.M) {
-	store, _ = postgres.New(DSN)
-	err := store.Init(context.TODO())
+	var err error
+	store, err = postgres.New(DSN)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	// migrations keep stored data, so the schema is dropped to start from scratch
+	_ = store.Drop(context.TODO())
+	err = store.Init(context.TODO())
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(2)
@@ -139,6 +148,104 @@ type Client struct {
 	pb.AnalyticAPIClient
 }
 
+// dial creates a client to the test server
+func dial(t *testing.T) *Client {
+	path := "127.0.0.1:4000"
+	conn, err := grpc.DialContext(context.TODO(), path, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("error creating client connection %s: %v", path, err)
+	}
+
+	return &Client{
+		conn:              conn,
+		AnalyticAPIClient: pb.NewAnalyticAPIClient(conn),
+	}
+}
+
+// tasks are not finished here, so totals checked by TestWriteEvent are not changed
+func TestWriteMessages(t *testing.T) {
+	cl := dial(t)
+	defer cl.conn.Close()
+
+	req := &pb.WriteMessagesRequest{
+		Messages: []*pb.WriteMessageRequest{
+			{EventType: models.Created, TaskID: 130, TimeStamp: timestamppb.New(timeStamp.Add(-10 * time.Second))},
+			{EventType: models.MessageSent, TaskID: 130, Approver: "approver130@mail.com", TimeStamp: timestamppb.New(timeStamp.Add(-9 * time.Second))},
+			// MESSAGE_SENT -> FINISHED is not a valid transition
+			{EventType: models.Finished, TaskID: 130, TimeStamp: timestamppb.New(timeStamp.Add(-8 * time.Second))},
+		},
+	}
+
+	resp, err := cl.WriteMessages(context.TODO(), req)
+	if err != nil {
+		t.Fatalf("unexpected error on batch: %v", err)
+	}
+	if resp.Accepted != 2 || resp.Rejected != 1 || len(resp.Results) != 3 {
+		t.Fatalf("wrong batch counters: %v", resp)
+	}
+	if resp.Results[2].Index != 2 || resp.Results[2].Accepted || resp.Results[2].Error == "" {
+		t.Fatalf("the last message has to be rejected: %v", resp.Results[2])
+	}
+	if codes.Code(resp.Results[2].Code) != codes.FailedPrecondition {
+		t.Fatalf("expected code %v, got %v", codes.FailedPrecondition, codes.Code(resp.Results[2].Code))
+	}
+}
+
+func TestWriteMessageCodes(t *testing.T) {
+	cl := dial(t)
+	defer cl.conn.Close()
+
+	ts := timestamppb.New(timeStamp.Add(-10 * time.Second))
+	tests := []struct {
+		name string
+		req  *pb.WriteMessageRequest
+		code codes.Code
+	}{
+		{name: "created", req: &pb.WriteMessageRequest{EventType: models.Created, TaskID: 132, TimeStamp: ts}, code: codes.OK},
+		{name: "sent", req: &pb.WriteMessageRequest{EventType: models.MessageSent, TaskID: 132, Approver: "approver132@mail.com", TimeStamp: ts}, code: codes.OK},
+		{name: "unknown event type", req: &pb.WriteMessageRequest{EventType: "UNKNOWN", TaskID: 132, TimeStamp: ts}, code: codes.InvalidArgument},
+		{name: "invalid transition", req: &pb.WriteMessageRequest{EventType: models.Created, TaskID: 132, TimeStamp: ts}, code: codes.FailedPrecondition},
+		{name: "approver mismatch", req: &pb.WriteMessageRequest{EventType: models.Approved, TaskID: 132, Approver: "other@mail.com", TimeStamp: ts}, code: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		_, err := cl.WriteMessage(context.TODO(), tt.req)
+		if status.Code(err) != tt.code {
+			t.Fatalf("%s: expected code %v, got %v", tt.name, tt.code, err)
+		}
+	}
+}
+
+func TestStreamMessages(t *testing.T) {
+	cl := dial(t)
+	defer cl.conn.Close()
+
+	stream, err := cl.StreamMessages(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected error on opening stream: %v", err)
+	}
+
+	// the second CREATED message is rejected
+	for i := 0; i < 2; i++ {
+		msgReq := &pb.WriteMessageRequest{
+			EventType: models.Created,
+			TaskID:    131,
+			TimeStamp: timestamppb.New(timeStamp.Add(-10 * time.Second)),
+		}
+		if err := stream.Send(msgReq); err != nil {
+			t.Fatalf("unexpected error on sending message %d: %v", i, err)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("unexpected error on closing stream: %v", err)
+	}
+	if resp.Accepted != 1 || resp.Rejected != 1 || !resp.Results[0].Accepted || resp.Results[1].Accepted {
+		t.Fatalf("wrong stream results: %v", resp)
+	}
+}
+
 func TestWriteEvent(t *testing.T) {
 
 	// create grpc client
@@ -183,7 +290,8 @@ func TestWriteEvent(t *testing.T) {
 		},
 	}
 
-	totals, delays, err := store.GetAggregates(ctx)
+	// final states are given by the transition table of the service
+	totals, delays, err := analytic.New(store).GetAggregates(ctx, nil)
 	if err != nil {
 		t.Fatalf("unexpected error on getting aggregates. %v", err)
 	}
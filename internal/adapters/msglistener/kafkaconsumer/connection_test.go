@@ -0,0 +1,99 @@
+package kafkaconsumer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSASLMechanism(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		name      string
+		wantErr   bool
+	}{
+		{mechanism: "", name: ""},
+		{mechanism: MechanismPlain, name: "PLAIN"},
+		{mechanism: MechanismSCRAMSHA256, name: "SCRAM-SHA-256"},
+		{mechanism: "SCRAM-SHA-512", name: "SCRAM-SHA-512"},
+		{mechanism: "gssapi", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		m, err := SASL{Mechanism: tt.mechanism, Username: "user", Password: "pass"}.mechanism()
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: unexpected error %v", tt.mechanism, err)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if (m == nil) != (tt.name == "") || (m != nil && m.Name() != tt.name) {
+			t.Fatalf("%s: wrong mechanism %v", tt.mechanism, m)
+		}
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	cfg, err := TLS{}.config()
+	if err != nil || cfg != nil {
+		t.Fatalf("disabled TLS has to give no config: %v, %v", cfg, err)
+	}
+
+	if _, err := (TLS{Enabled: true, CAFile: "missing.pem"}).config(); err == nil {
+		t.Fatalf("missing CA file has not been reported")
+	}
+
+	certFile, keyFile := writeCertificate(t)
+	cfg, err = TLS{Enabled: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile}.config()
+	if err != nil {
+		t.Fatalf("unexpected error on TLS config: %v", err)
+	}
+	if cfg.RootCAs == nil || len(cfg.Certificates) != 1 {
+		t.Fatalf("certificates have not been loaded: %v", cfg)
+	}
+}
+
+// writeCertificate creates a self-signed certificate and its key in a temporary directory
+func writeCertificate(t *testing.T) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("error marshaling key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600); err != nil {
+		t.Fatalf("error writing certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+
+	return certFile, keyFile
+}
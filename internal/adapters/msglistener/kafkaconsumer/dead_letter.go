@@ -0,0 +1,78 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+	"github.com/segmentio/kafka-go"
+)
+
+// headers added to messages written to the dead-letter topic
+const (
+	HeaderError      = "dlq-error"
+	HeaderTopic      = "dlq-topic"
+	HeaderPartition  = "dlq-partition"
+	HeaderOffset     = "dlq-offset"
+	HeaderRejectedAt = "dlq-rejected-at"
+)
+
+var _ ports.DeadLetterSink = &DLQWriter{}
+
+// DLQWriter sends rejected messages to a dead-letter kafka topic.
+// The original payload is kept as is, the error and the source position are passed in headers
+type DLQWriter struct {
+	Writer *kafka.Writer
+}
+
+// NewDLQWriter creates a writer to the dead-letter topic
+func NewDLQWriter(conn Connection, topic string) (*DLQWriter, error) {
+	if len(conn.Brokers) == 0 || topic == "" {
+		return nil, fmt.Errorf("missed some connection parameters: brokers %v, topic %s", conn.Brokers, topic)
+	}
+
+	transport, err := conn.transport()
+	if err != nil {
+		return nil, fmt.Errorf("error configuring kafka connection: %v", err)
+	}
+
+	return &DLQWriter{
+		Writer: &kafka.Writer{
+			Addr:      kafka.TCP(conn.Brokers...),
+			Topic:     topic,
+			Balancer:  &kafka.LeastBytes{},
+			Transport: transport,
+		},
+	}, nil
+}
+
+// Reject writes the message to the dead-letter topic
+func (w *DLQWriter) Reject(ctx context.Context, msg *models.RejectedMessage) error {
+	if msg.RejectedAt.IsZero() {
+		msg.RejectedAt = time.Now()
+	}
+
+	err := w.Writer.WriteMessages(ctx, kafka.Message{
+		Value: msg.Payload,
+		Headers: []kafka.Header{
+			{Key: HeaderError, Value: []byte(msg.Error)},
+			{Key: HeaderTopic, Value: []byte(msg.Topic)},
+			{Key: HeaderPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+			{Key: HeaderOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+			{Key: HeaderRejectedAt, Value: []byte(msg.RejectedAt.Format(time.RFC3339Nano))},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error writing message to dead-letter topic: %v", err)
+	}
+
+	return nil
+}
+
+// Close flushes pending messages and closes the writer
+func (w *DLQWriter) Close() error {
+	return w.Writer.Close()
+}
@@ -0,0 +1,125 @@
+package kafkaconsumer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	pb "github.com/seggga/approve-analytics/pkg/proto/analytics"
+	"google.golang.org/protobuf/proto"
+)
+
+// formats of message values
+const (
+	// FormatJSON is models.Message encoded by encoding/json
+	FormatJSON = "json"
+	// FormatProtobuf is a serialized WriteMessageRequest of the gRPC API
+	FormatProtobuf = "protobuf"
+	// FormatCloudEvents is a CloudEvents envelope in structured JSON mode, data or data_base64 keeps
+	// a JSON message. Binary mode is not supported, since dead letters keep the value without ce_ headers
+	FormatCloudEvents = "cloudevents"
+)
+
+// Decoder parses a message value
+type Decoder func(value []byte) (*models.Message, error)
+
+var decoders = map[string]Decoder{
+	FormatJSON:        decodeJSON,
+	FormatProtobuf:    decodeProtobuf,
+	FormatCloudEvents: decodeCloudEvent,
+}
+
+// decoderFor returns the decoder of the format, empty format means JSON
+func decoderFor(format string) (Decoder, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+
+	d, ok := decoders[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown message format %s: expected %s, %s or %s", format, FormatJSON, FormatProtobuf, FormatCloudEvents)
+	}
+	return d, nil
+}
+
+func decodeJSON(value []byte) (*models.Message, error) {
+	msg := &models.Message{}
+	if err := json.Unmarshal(value, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func decodeProtobuf(value []byte) (*models.Message, error) {
+	req := &pb.WriteMessageRequest{}
+	if err := proto.Unmarshal(value, req); err != nil {
+		return nil, err
+	}
+
+	msg := &models.Message{
+		ID:        req.GetMessageID(),
+		EventType: req.GetEventType(),
+		TaskID:    req.GetTaskID(),
+		Approver:  req.GetApprover(),
+	}
+	if req.GetTimeStamp() != nil {
+		msg.RecievedAt = req.GetTimeStamp().AsTime()
+	}
+	return msg, nil
+}
+
+// cloudEvent is a CloudEvents 1.0 envelope, attributes not used by analytics are skipped
+type cloudEvent struct {
+	SpecVersion string          `json:"specversion"`
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Time        time.Time       `json:"time"`
+	Data        json.RawMessage `json:"data"`
+	DataBase64  []byte          `json:"data_base64"`
+}
+
+// decodeCloudEvent takes the message from data, the event id, type and time
+// fill message fields that are not set in data
+func decodeCloudEvent(value []byte) (*models.Message, error) {
+	evt := cloudEvent{}
+	if err := json.Unmarshal(value, &evt); err != nil {
+		return nil, err
+	}
+	if evt.SpecVersion == "" {
+		return nil, fmt.Errorf("not a cloud event in structured mode: specversion is missing")
+	}
+
+	data := []byte(evt.Data)
+	if len(evt.DataBase64) != 0 {
+		data = evt.DataBase64
+	}
+
+	msg := &models.Message{}
+	if len(data) != 0 {
+		if err := json.Unmarshal(data, msg); err != nil {
+			return nil, fmt.Errorf("error parsing data of cloud event %s: %v", evt.ID, err)
+		}
+	}
+	if msg.ID == "" {
+		msg.ID = evt.ID
+	}
+	if msg.EventType == "" {
+		msg.EventType = cloudEventType(evt.Type)
+	}
+	if msg.RecievedAt.IsZero() {
+		msg.RecievedAt = evt.Time
+	}
+
+	return msg, nil
+}
+
+// cloudEventType maps a cloud event type to the event type. Reverse-DNS types are named
+// after the last segment, so com.example.task.message_sent is MESSAGE_SENT
+func cloudEventType(ceType string) string {
+	if i := strings.LastIndex(ceType, "."); i >= 0 {
+		ceType = ceType[i+1:]
+	}
+	return strings.ToUpper(ceType)
+}
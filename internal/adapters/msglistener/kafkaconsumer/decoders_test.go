@@ -0,0 +1,105 @@
+package kafkaconsumer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	pb "github.com/seggga/approve-analytics/pkg/proto/analytics"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestDecoders(t *testing.T) {
+	ts := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	expected := models.Message{
+		ID:         "msg-1",
+		EventType:  models.Approved,
+		TaskID:     42,
+		Approver:   "approver@mail.com",
+		RecievedAt: ts,
+	}
+
+	protoValue, err := proto.Marshal(&pb.WriteMessageRequest{
+		MessageID: "msg-1",
+		EventType: models.Approved,
+		TaskID:    42,
+		Approver:  "approver@mail.com",
+		TimeStamp: timestamppb.New(ts),
+	})
+	if err != nil {
+		t.Fatalf("error marshaling request: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		value   []byte
+		wantErr bool
+	}{
+		{
+			name:   "json",
+			format: FormatJSON,
+			value:  []byte(`{"id":"msg-1","eventtype":"APPROVED","taskid":42,"approver":"approver@mail.com","recievedat":"2022-08-01T10:00:00Z"}`),
+		},
+		{
+			name:   "protobuf",
+			format: FormatProtobuf,
+			value:  protoValue,
+		},
+		{
+			name:   "cloud event",
+			format: FormatCloudEvents,
+			value: []byte(`{"specversion":"1.0","id":"msg-1","source":"/tasks","type":"APPROVED","time":"2022-08-01T10:00:00Z",
+				"datacontenttype":"application/json","data":{"taskid":42,"approver":"approver@mail.com"}}`),
+		},
+		{
+			name:   "cloud event of reverse-DNS type",
+			format: FormatCloudEvents,
+			value: []byte(`{"specversion":"1.0","id":"msg-1","source":"/tasks","type":"com.example.task.approved",
+				"time":"2022-08-01T10:00:00Z","data":{"taskid":42,"approver":"approver@mail.com"}}`),
+		},
+		{
+			name:   "cloud event with base64 data",
+			format: FormatCloudEvents,
+			value: []byte(`{"specversion":"1.0","id":"msg-1","source":"/tasks","type":"APPROVED","time":"2022-08-01T10:00:00Z",
+				"data_base64":"eyJ0YXNraWQiOjQyLCJhcHByb3ZlciI6ImFwcHJvdmVyQG1haWwuY29tIn0="}`),
+		},
+		{
+			// binary mode keeps attributes in ce_ headers, the value is data only
+			name:    "cloud event in binary mode",
+			format:  FormatCloudEvents,
+			value:   []byte(`{"taskid":42,"approver":"approver@mail.com"}`),
+			wantErr: true,
+		},
+		{
+			name:    "not a cloud event",
+			format:  FormatCloudEvents,
+			value:   []byte(`{"eventtype":"APPROVED","taskid":42}`),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		d, err := decoderFor(tt.format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+
+		msg, err := d(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if msg.ID != expected.ID || msg.EventType != expected.EventType || msg.TaskID != expected.TaskID ||
+			msg.Approver != expected.Approver || !msg.RecievedAt.Equal(expected.RecievedAt) {
+			t.Fatalf("%s: expected %v, got %v", tt.name, expected, *msg)
+		}
+	}
+
+	if _, err := decoderFor("avro"); err == nil {
+		t.Fatalf("unknown format has not been reported")
+	}
+}
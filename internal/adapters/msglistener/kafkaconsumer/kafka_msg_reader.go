@@ -2,42 +2,177 @@ package kafkaconsumer
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
+	"time"
 
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
 	"github.com/seggga/approve-analytics/internal/domain/models"
 	"github.com/seggga/approve-analytics/internal/ports"
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 )
 
-var _ ports.MsgListener = &Client{}
+// delays between attempts to process a message while the storage is failing, a message still failing
+// after maxRetries is rejected, so a message the storage never accepts does not stall its worker
+const (
+	retryBackoff    = time.Second
+	maxRetryBackoff = 30 * time.Second
+	maxRetries      = 10
+
+	// stopTimeout limits time given to Start to finish on Stop
+	stopTimeout = 10 * time.Second
+)
+
+var (
+	_ ports.MsgListener = &Client{}
+	_ ports.MsgReplayer = &Client{}
+)
+
+// default consumption parameters
+const (
+	defaultWorkers        = 1
+	defaultQueueSize      = 100
+	defaultCommitInterval = time.Second
+	defaultMinBytes       = 10e1
+	defaultMaxBytes       = 10e6
+)
+
+// start offsets used by a consumer group without committed offsets
+const (
+	StartOffsetFirst = "first"
+	StartOffsetLast  = "last"
+)
+
+// Topic is a consumed topic and the format of its messages, JSON by default
+type Topic struct {
+	Name   string
+	Format string
+}
+
+// Config keeps connection and consumption parameters
+type Config struct {
+	Connection
+	Topics  []Topic
+	GroupID string
+
+	// MinBytes and MaxBytes limit the size of a fetch response, MaxWait limits waiting for MinBytes
+	MinBytes int
+	MaxBytes int
+	MaxWait  time.Duration
+	// StartOffset is either StartOffsetFirst (by default) or StartOffsetLast
+	StartOffset string
+
+	// Workers is a number of messages processed concurrently. Messages of a task are processed
+	// by the same worker in the order they are fetched
+	Workers int
+	// QueueSize limits fetched messages waiting for a worker, fetching is paused while the queue is full
+	QueueSize int
+	// CommitInterval is a period of committing processed messages
+	CommitInterval time.Duration
+}
+
+// withDefaults fills parameters that are not set
+func (cfg Config) withDefaults() Config {
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.CommitInterval <= 0 {
+		cfg.CommitInterval = defaultCommitInterval
+	}
+	if cfg.MinBytes <= 0 {
+		cfg.MinBytes = defaultMinBytes
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultMaxBytes
+	}
+	return cfg
+}
+
+// startOffset converts StartOffset to the kafka value
+func (cfg Config) startOffset() (int64, error) {
+	switch cfg.StartOffset {
+	case "", StartOffsetFirst:
+		return kafka.FirstOffset, nil
+	case StartOffsetLast:
+		return kafka.LastOffset, nil
+	default:
+		return 0, fmt.Errorf("unknown start offset %s: expected %s or %s", cfg.StartOffset, StartOffsetFirst, StartOffsetLast)
+	}
+}
 
 // Client ...
 type Client struct {
 	Reader *kafka.Reader
 
-	logger *zap.Logger
-	an     ports.Analyter
+	cfg     Config
+	logger  *zap.Logger
+	an      ports.Analyter
+	dlq     ports.DeadLetterSink
+	offsets *offsetTracker
+	// decoders parse messages of every topic
+	decoders map[string]Decoder
+	// backoff is the first delay between attempts to process a message
+	backoff time.Duration
+
+	mu      sync.Mutex
+	running chan struct{}
 }
 
-// New ...
-func New(broker, topic, groupID string, logger *zap.Logger, an ports.Analyter) (*Client, error) {
-	if broker == "" || topic == "" || groupID == "" {
-		return nil, fmt.Errorf("missed some connection parameters: brokers %s, topic %s, groupID %s", broker, topic, groupID)
+// New creates a kafka consumer. Messages that cannot be parsed or processed are passed to dlq
+// and committed, nil dlq means such messages are only logged
+func New(cfg Config, logger *zap.Logger, an ports.Analyter, dlq ports.DeadLetterSink) (*Client, error) {
+	if len(cfg.Brokers) == 0 || len(cfg.Topics) == 0 || cfg.GroupID == "" {
+		return nil, fmt.Errorf("missed some connection parameters: brokers %v, topics %v, groupID %s", cfg.Brokers, cfg.Topics, cfg.GroupID)
+	}
+
+	topics := make([]string, 0, len(cfg.Topics))
+	decoders := make(map[string]Decoder, len(cfg.Topics))
+	for _, topic := range cfg.Topics {
+		if topic.Name == "" {
+			return nil, fmt.Errorf("missed topic name: %v", cfg.Topics)
+		}
+		d, err := decoderFor(topic.Format)
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %v", topic.Name, err)
+		}
+		topics = append(topics, topic.Name)
+		decoders[topic.Name] = d
+	}
+
+	dialer, err := cfg.dialer()
+	if err != nil {
+		return nil, fmt.Errorf("error configuring kafka connection: %v", err)
+	}
+	startOffset, err := cfg.startOffset()
+	if err != nil {
+		return nil, err
 	}
 
 	c := Client{
-		logger: logger,
-		an:     an,
+		cfg:      cfg.withDefaults(),
+		logger:   logger,
+		an:       an,
+		dlq:      dlq,
+		offsets:  newOffsetTracker(),
+		decoders: decoders,
+		backoff:  retryBackoff,
 	}
 
 	c.Reader = kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  []string{broker},
-		Topic:    topic,
-		GroupID:  groupID,
-		MinBytes: 10e1,
-		MaxBytes: 10e6,
+		Brokers:     cfg.Brokers,
+		GroupTopics: topics,
+		GroupID:     cfg.GroupID,
+		Dialer:      dialer,
+		MinBytes:    c.cfg.MinBytes,
+		MaxBytes:    c.cfg.MaxBytes,
+		MaxWait:     c.cfg.MaxWait,
+		StartOffset: startOffset,
 	})
 
 	return &c, nil
@@ -48,58 +183,168 @@ func (c *Client) ProcessMessage(ctx context.Context, msg *models.Message) error
 	err := c.an.WriteEvent(ctx, msg)
 	if err != nil {
 		c.logger.Sugar().Debugf("error writing message: %v", err)
-		return fmt.Errorf("error writing message: %v", err)
+		return fmt.Errorf("error writing message: %w", err)
 	}
 
 	c.logger.Debug("message has been written")
 	return nil
 }
 
-// Start sequentaly reads messages from kafka, commits messages after processing
+// Start reads messages from kafka and passes them to workers chosen by task ID, so messages
+// of a task are processed in order. Processed messages are committed periodically.
+// Start returns when ctx is done, messages fetched and not processed yet are left uncommitted
 func (c *Client) Start(ctx context.Context) error {
-	msg := &models.Message{}
-	next := true
+	running := make(chan struct{})
+	c.mu.Lock()
+	c.running = running
+	c.mu.Unlock()
+	defer close(running)
 
-	for next {
-		select {
+	queues := make([]chan job, c.cfg.Workers)
+	var workers sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan job, c.cfg.QueueSize)
+		workers.Add(1)
+		go func(queue <-chan job) {
+			defer workers.Done()
+			c.work(ctx, queue)
+		}(queues[i])
+	}
 
-		case <-ctx.Done():
-			c.logger.Debug("context has been closed")
-			next = false
+	stopCommits := make(chan struct{})
+	commitsStopped := make(chan struct{})
+	go func() {
+		defer close(commitsStopped)
+		c.commitPeriodically(stopCommits)
+	}()
+
+	for ctx.Err() == nil {
+		kafkaMsg, err := c.Reader.FetchMessage(ctx)
+		if errors.Is(err, io.EOF) {
+			c.logger.Debug("reader has been closed")
 			break
+		}
+		if err != nil {
+			c.logger.Sugar().Debugf("error fetching message: %v", err)
+			continue
+		}
 
-		default:
-			kafkaMsg, err := c.Reader.FetchMessage(ctx)
-			if err != nil {
-				c.logger.Sugar().Debugf("error fetching message: %v", err)
-				continue
-			}
+		c.logger.Sugar().Debugf("got []byte message %v", kafkaMsg)
 
-			c.logger.Sugar().Debugf("got []byte message %v", kafkaMsg)
+		j := c.decode(kafkaMsg)
+		c.offsets.fetched(kafkaMsg)
 
-			err = json.Unmarshal(kafkaMsg.Value, msg)
-			if err != nil {
-				c.logger.Sugar().Debugf("failed unmarshal kafka message %v: %v", kafkaMsg, err)
-				continue
-			}
+		// the queue is full while its worker is busy, then fetching waits
+		select {
+		case queues[j.msg.TaskID%uint64(len(queues))] <- j:
+		case <-ctx.Done():
+		}
+	}
+	c.logger.Debug("context has been closed")
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	workers.Wait()
+	close(stopCommits)
+	<-commitsStopped
 
-			c.logger.Sugar().Debugf("parsed message %v", msg)
+	return nil
+}
+
+// processWithRetry processes the message, storage failures are retried maxRetries times with a growing
+// backoff, so the message is not rejected while the database is briefly down
+func (c *Client) processWithRetry(ctx context.Context, msg *models.Message) error {
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := c.ProcessMessage(ctx, msg)
+		if !errors.Is(err, analytic.ErrStorage) {
+			return err
+		}
+		if attempt == maxRetries {
+			c.logger.Sugar().Errorf("storage failure, message %v is given up after %d retries: %v", msg, maxRetries, err)
+			return err
+		}
 
-			if err := c.ProcessMessage(ctx, msg); err != nil {
-				c.logger.Sugar().Debugf("failed message processing %v: %v", msg, err)
-				continue
-			}
+		c.logger.Sugar().Errorf("storage failure, message %v is retried in %v: %v", msg, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
 
-			if err := c.Reader.CommitMessages(ctx, kafkaMsg); err != nil {
-				c.logger.Sugar().Debugf("error committing message: %v", err)
-				continue
-			}
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
 		}
 	}
-	return nil
 }
 
-// Stop ...
+// reject passes the message to the dead-letter sink, the result reports whether the sink has taken it
+func (c *Client) reject(ctx context.Context, kafkaMsg kafka.Message, reason error) bool {
+	if c.dlq == nil {
+		return false
+	}
+
+	err := c.dlq.Reject(ctx, &models.RejectedMessage{
+		Topic:     kafkaMsg.Topic,
+		Partition: kafkaMsg.Partition,
+		Offset:    kafkaMsg.Offset,
+		Payload:   kafkaMsg.Value,
+		Error:     reason.Error(),
+	})
+	if err != nil {
+		c.logger.Sugar().Errorf("cannot reject message at offset %d of %s: %v", kafkaMsg.Offset, kafkaMsg.Topic, err)
+		return false
+	}
+
+	c.logger.Sugar().Infof("message at offset %d of %s has been rejected: %v", kafkaMsg.Offset, kafkaMsg.Topic, reason)
+	return true
+}
+
+// Replay parses and processes the payload of a rejected message once again,
+// the payload is parsed in the format of its topic
+func (c *Client) Replay(ctx context.Context, rejected *models.RejectedMessage) error {
+	msg, err := c.decoder(rejected.Topic)(rejected.Payload)
+	if err != nil {
+		return fmt.Errorf("%w: failed unmarshal rejected message %d: %v", analytic.ErrInvalidMessage, rejected.ID, err)
+	}
+	// messages rejected by analytics itself have no position in kafka
+	if msg.ID == "" && rejected.Offset >= 0 {
+		msg.ID = messageID(rejected.Topic, rejected.Partition, rejected.Offset)
+	}
+
+	return c.ProcessMessage(ctx, msg)
+}
+
+// decoder returns the decoder of the topic. Messages of other topics, e.g. the ones
+// rejected by analytics itself, are kept in JSON
+func (c *Client) decoder(topic string) Decoder {
+	if d, ok := c.decoders[topic]; ok {
+		return d
+	}
+	return decodeJSON
+}
+
+// messageID identifies a message without an id given by the producer by its position in kafka,
+// so a redelivered message is recognized as a duplicate
+func messageID(topic string, partition int, offset int64) string {
+	return fmt.Sprintf("kafka:%s/%d/%d", topic, partition, offset)
+}
+
+// Stop waits for Start to commit processed messages and closes the reader
 func (c *Client) Stop() error {
+	c.mu.Lock()
+	running := c.running
+	c.mu.Unlock()
+
+	if running != nil {
+		select {
+		case <-running:
+		case <-time.After(stopTimeout):
+			c.logger.Sugar().Errorf("consumer has not stopped in %v", stopTimeout)
+		}
+	}
+
 	return c.Reader.Close()
 }
@@ -1,5 +1,7 @@
+//go:build integration
+
 // Integration test, depends on running kafka, zookeeper and postgres instances
-// started by command: make kafka/compose/up
+// started by command: make kafka/compose/up, run by command: make kafka/test
 
 package kafkaconsumer
 
@@ -125,14 +127,21 @@ var (
 )
 
 func TestMain(m *testing.M) {
-	store, _ = postgres.New(DSN)
-	err := store.Init(context.TODO())
+	var err error
+	store, err = postgres.New(DSN)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	// migrations keep stored data, so the schema is dropped to start from scratch
+	_ = store.Drop(context.TODO())
+	err = store.Init(context.TODO())
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(2)
 	}
 	logger, _ := zap.NewDevelopment()
-	c, err = New(broker, topic, groupID, logger, analytic.New(store))
+	c, err = New(Config{Connection: Connection{Brokers: []string{broker}}, Topics: []Topic{{Name: topic}}, GroupID: groupID, Workers: 4}, logger, analytic.New(store), store)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(3)
@@ -194,7 +203,8 @@ func TestClient(t *testing.T) {
 		},
 	}
 
-	totals, delays, err := store.GetAggregates(ctx)
+	// final states are given by the transition table of the service
+	totals, delays, err := analytic.New(store).GetAggregates(ctx, nil)
 	if err != nil {
 		t.Fatalf("unexpected error on getting aggregates. %v", err)
 	}
@@ -0,0 +1,113 @@
+package kafkaconsumer
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// offsetTracker finds messages that can be committed when messages are processed concurrently:
+// a message is committed only after all messages fetched before it from the same partition are processed.
+// A message parked by analytics is processed once analytics releases it
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partition]*partitionOffsets
+	parked     map[string]kafka.Message
+}
+
+type partition struct {
+	topic string
+	id    int
+}
+
+// partitionOffsets keeps offsets fetched and not committed yet in the order they have been fetched
+type partitionOffsets struct {
+	pending   []int64
+	processed map[int64]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[partition]*partitionOffsets),
+		parked:     make(map[string]kafka.Message),
+	}
+}
+
+// fetched registers the message, messages of a partition have to be registered in the order they are fetched
+func (t *offsetTracker) fetched(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := partition{topic: msg.Topic, id: msg.Partition}
+	p, ok := t.partitions[key]
+	if !ok {
+		p = &partitionOffsets{processed: make(map[int64]bool)}
+		t.partitions[key] = p
+	}
+	p.pending = append(p.pending, msg.Offset)
+}
+
+// processed marks the message as the one that can be committed
+func (t *offsetTracker) processed(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if p, ok := t.partitions[partition{topic: msg.Topic, id: msg.Partition}]; ok {
+		p.processed[msg.Offset] = true
+	}
+}
+
+// park keeps the message with the id uncommitted till it is released
+func (t *offsetTracker) park(id string, msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.parked[id] = msg
+}
+
+// release marks parked messages as processed unless they are still parked
+func (t *offsetTracker) release(stillParked func(id string) bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, msg := range t.parked {
+		if stillParked(id) {
+			continue
+		}
+		delete(t.parked, id)
+		if p, ok := t.partitions[partition{topic: msg.Topic, id: msg.Partition}]; ok {
+			p.processed[msg.Offset] = true
+		}
+	}
+}
+
+// committable returns the last message of every partition that is processed along with all messages
+// fetched before it. Returned messages are forgotten, so every message is returned once
+func (t *offsetTracker) committable() []kafka.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var msgs []kafka.Message
+	for key, p := range t.partitions {
+		n := 0
+		for n < len(p.pending) && p.processed[p.pending[n]] {
+			delete(p.processed, p.pending[n])
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+
+		msgs = append(msgs, kafka.Message{Topic: key.topic, Partition: key.id, Offset: p.pending[n-1]})
+		p.pending = p.pending[n:]
+	}
+
+	sort.Slice(msgs, func(i, j int) bool {
+		if msgs[i].Topic != msgs[j].Topic {
+			return msgs[i].Topic < msgs[j].Topic
+		}
+		return msgs[i].Partition < msgs[j].Partition
+	})
+	return msgs
+}
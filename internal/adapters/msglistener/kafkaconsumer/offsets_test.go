@@ -0,0 +1,61 @@
+package kafkaconsumer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	tr := newOffsetTracker()
+	msg := func(partition int, offset int64) kafka.Message {
+		return kafka.Message{Topic: "events", Partition: partition, Offset: offset}
+	}
+
+	for _, m := range []kafka.Message{msg(0, 0), msg(0, 1), msg(0, 2), msg(1, 10), msg(0, 3), msg(1, 11)} {
+		tr.fetched(m)
+	}
+
+	steps := []struct {
+		processed []kafka.Message
+		expected  []kafka.Message
+	}{
+		// offset 0 is not processed yet
+		{processed: []kafka.Message{msg(0, 1), msg(0, 2), msg(1, 11)}, expected: nil},
+		{processed: []kafka.Message{msg(0, 0)}, expected: []kafka.Message{msg(0, 2)}},
+		{processed: []kafka.Message{msg(1, 10), msg(0, 3)}, expected: []kafka.Message{msg(0, 3), msg(1, 11)}},
+		// everything has been returned
+		{processed: nil, expected: nil},
+	}
+
+	for i, step := range steps {
+		for _, m := range step.processed {
+			tr.processed(m)
+		}
+		if got := tr.committable(); !reflect.DeepEqual(got, step.expected) {
+			t.Fatalf("step %d: expected %v, got %v", i, step.expected, got)
+		}
+	}
+}
+
+func TestOffsetTrackerParked(t *testing.T) {
+	tr := newOffsetTracker()
+	msg := kafka.Message{Topic: "events", Partition: 0, Offset: 5}
+	tr.fetched(msg)
+	tr.park("kafka:events/0/5", msg)
+
+	parked := true
+	stillParked := func(id string) bool { return parked }
+
+	tr.release(stillParked)
+	if got := tr.committable(); got != nil {
+		t.Fatalf("parked message has been committed: %v", got)
+	}
+
+	parked = false
+	tr.release(stillParked)
+	if got := tr.committable(); !reflect.DeepEqual(got, []kafka.Message{msg}) {
+		t.Fatalf("released message has not been committed: %v", got)
+	}
+}
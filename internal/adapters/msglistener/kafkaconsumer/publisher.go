@@ -0,0 +1,80 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// HeaderEventType keeps the type of a published analytics event
+const HeaderEventType = "event-type"
+
+var _ ports.Publisher = &Publisher{}
+
+// Publisher sends analytics events to a kafka topic as JSON. The task ID is the message key,
+// so events of a task get to the same partition and keep their order
+type Publisher struct {
+	Writer *kafka.Writer
+
+	logger *zap.Logger
+}
+
+// NewPublisher creates an asynchronous writer to the topic, failed writes are logged
+func NewPublisher(conn Connection, topic string, logger *zap.Logger) (*Publisher, error) {
+	if len(conn.Brokers) == 0 || topic == "" {
+		return nil, fmt.Errorf("missed some connection parameters: brokers %v, topic %s", conn.Brokers, topic)
+	}
+
+	transport, err := conn.transport()
+	if err != nil {
+		return nil, fmt.Errorf("error configuring kafka connection: %v", err)
+	}
+
+	p := &Publisher{logger: logger}
+	p.Writer = &kafka.Writer{
+		Addr:      kafka.TCP(conn.Brokers...),
+		Topic:     topic,
+		Balancer:  &kafka.Hash{},
+		Transport: transport,
+		Async:     true,
+		Completion: func(msgs []kafka.Message, err error) {
+			if err != nil {
+				p.logger.Sugar().Errorf("error publishing %d analytics events: %v", len(msgs), err)
+			}
+		},
+	}
+
+	return p, nil
+}
+
+// Publish queues events for sending
+func (p *Publisher) Publish(ctx context.Context, events ...models.AnalyticsEvent) {
+	msgs := make([]kafka.Message, 0, len(events))
+	for _, evt := range events {
+		value, err := json.Marshal(evt)
+		if err != nil {
+			p.logger.Sugar().Errorf("error marshaling analytics event %v: %v", evt, err)
+			continue
+		}
+		msgs = append(msgs, kafka.Message{
+			Key:     []byte(strconv.FormatUint(evt.TaskID, 10)),
+			Value:   value,
+			Headers: []kafka.Header{{Key: HeaderEventType, Value: []byte(evt.Type)}},
+		})
+	}
+
+	if err := p.Writer.WriteMessages(ctx, msgs...); err != nil {
+		p.logger.Sugar().Errorf("error publishing analytics events %v: %v", events, err)
+	}
+}
+
+// Close flushes queued events and closes the writer
+func (p *Publisher) Close() error {
+	return p.Writer.Close()
+}
@@ -0,0 +1,103 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/segmentio/kafka-go"
+)
+
+// commitTimeout limits a commit, commits are made after the context of Start is done as well
+const commitTimeout = 5 * time.Second
+
+// job is a fetched message, err is set if the message cannot be parsed
+type job struct {
+	kafkaMsg kafka.Message
+	msg      *models.Message
+	err      error
+}
+
+// decode parses the message in the format of its topic,
+// a message without an id gets the one derived from its position
+func (c *Client) decode(kafkaMsg kafka.Message) job {
+	msg, err := c.decoder(kafkaMsg.Topic)(kafkaMsg.Value)
+	if err != nil {
+		c.logger.Sugar().Debugf("failed unmarshal kafka message %v: %v", kafkaMsg, err)
+		return job{kafkaMsg: kafkaMsg, msg: &models.Message{}, err: err}
+	}
+
+	if msg.ID == "" {
+		msg.ID = messageID(kafkaMsg.Topic, kafkaMsg.Partition, kafkaMsg.Offset)
+	}
+	c.logger.Sugar().Debugf("parsed message %v", msg)
+
+	return job{kafkaMsg: kafkaMsg, msg: msg}
+}
+
+// work processes jobs of the queue one by one. Messages that are processed or failed can be committed,
+// a failed message is lost if the dead-letter sink does not take it, otherwise the partition would stop
+// committing for good. Messages parked by analytics are committed once they are released,
+// the ones left after ctx is done are skipped
+func (c *Client) work(ctx context.Context, queue <-chan job) {
+	for j := range queue {
+		if ctx.Err() != nil {
+			continue
+		}
+
+		err := j.err
+		if err == nil {
+			err = c.processWithRetry(ctx, j.msg)
+			if err != nil && ctx.Err() != nil {
+				continue
+			}
+		}
+		if err != nil && c.an.Park(ctx, j.msg, err) {
+			c.logger.Sugar().Debugf("message %v is parked till its predecessor arrives: %v", j.msg, err)
+			c.offsets.park(j.msg.ID, j.kafkaMsg)
+			continue
+		}
+		if err != nil {
+			c.logger.Sugar().Debugf("failed message processing %v: %v", j.msg, err)
+			if !c.reject(ctx, j.kafkaMsg, err) {
+				c.logger.Sugar().Errorf("message at offset %d of %s is skipped: %v", j.kafkaMsg.Offset, j.kafkaMsg.Topic, err)
+			}
+		}
+
+		c.offsets.processed(j.kafkaMsg)
+	}
+}
+
+// commitPeriodically commits processed messages until stop is closed, then makes the last commit
+func (c *Client) commitPeriodically(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.cfg.CommitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.commit()
+		case <-stop:
+			c.commit()
+			return
+		}
+	}
+}
+
+// commit commits the last processed message of every partition
+func (c *Client) commit() {
+	c.offsets.release(c.an.Parked)
+	msgs := c.offsets.committable()
+	if len(msgs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commitTimeout)
+	defer cancel()
+
+	if err := c.Reader.CommitMessages(ctx, msgs...); err != nil {
+		c.logger.Sugar().Errorf("error committing messages %v: %v", msgs, err)
+		return
+	}
+	c.logger.Sugar().Debugf("messages committed: %v", msgs)
+}
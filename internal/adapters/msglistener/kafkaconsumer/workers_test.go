@@ -0,0 +1,84 @@
+package kafkaconsumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// failingAnalyter fails the first failures writes with err
+type failingAnalyter struct {
+	ports.Analyter
+	err      error
+	failures int
+	calls    int
+}
+
+func (a *failingAnalyter) WriteEvent(ctx context.Context, msg *models.Message) error {
+	a.calls++
+	if a.calls <= a.failures {
+		return a.err
+	}
+	return nil
+}
+
+func (a *failingAnalyter) Park(ctx context.Context, msg *models.Message, reason error) bool {
+	return false
+}
+
+func TestProcessWithRetry(t *testing.T) {
+	storageErr := fmt.Errorf("%w: connection refused", analytic.ErrStorage)
+
+	tests := []struct {
+		name      string
+		an        *failingAnalyter
+		wantErr   error
+		wantCalls int
+	}{
+		{"recovered storage", &failingAnalyter{err: storageErr, failures: 3}, nil, 4},
+		{"permanent storage failure", &failingAnalyter{err: storageErr, failures: maxRetries + 100}, analytic.ErrStorage, maxRetries + 1},
+		{"invalid message", &failingAnalyter{err: analytic.ErrInvalidTransition, failures: 100}, analytic.ErrInvalidTransition, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{logger: zap.NewNop(), an: tt.an, backoff: time.Microsecond}
+
+			err := c.processWithRetry(context.TODO(), &models.Message{TaskID: 1})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.an.calls != tt.wantCalls {
+				t.Fatalf("expected %d attempts, got %d", tt.wantCalls, tt.an.calls)
+			}
+		})
+	}
+}
+
+func TestWorkWithoutDeadLetterSink(t *testing.T) {
+	c := &Client{
+		logger:  zap.NewNop(),
+		an:      &failingAnalyter{err: analytic.ErrInvalidMessage, failures: 1},
+		offsets: newOffsetTracker(),
+	}
+
+	kafkaMsg := kafka.Message{Topic: "events", Partition: 0, Offset: 7}
+	c.offsets.fetched(kafkaMsg)
+	queue := make(chan job, 1)
+	queue <- job{kafkaMsg: kafkaMsg, msg: &models.Message{TaskID: 1}}
+	close(queue)
+
+	c.work(context.TODO(), queue)
+	if got := c.offsets.committable(); !reflect.DeepEqual(got, []kafka.Message{kafkaMsg}) {
+		t.Fatalf("failed message has blocked the partition: %v", got)
+	}
+}
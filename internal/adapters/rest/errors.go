@@ -0,0 +1,22 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+)
+
+// errorStatus maps an error returned by analytics to an HTTP status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, analytic.ErrStorage):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, analytic.ErrInvalidMessage),
+		errors.Is(err, analytic.ErrInvalidTransition),
+		errors.Is(err, analytic.ErrApproverMismatch):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,29 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{err: fmt.Errorf("%w: connection refused", analytic.ErrStorage), status: http.StatusServiceUnavailable},
+		{err: fmt.Errorf("%w: unknown event type", analytic.ErrInvalidMessage), status: http.StatusUnprocessableEntity},
+		{err: fmt.Errorf("error replaying message 1: %w", analytic.ErrInvalidTransition), status: http.StatusUnprocessableEntity},
+		{err: fmt.Errorf("%w: approvers are not equal", analytic.ErrApproverMismatch), status: http.StatusUnprocessableEntity},
+		{err: errors.New("unknown"), status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if status := errorStatus(tt.err); status != tt.status {
+			t.Fatalf("%v: expected %d, got %d", tt.err, tt.status, status)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// startNode stands for a task that has not been stored yet. Nodes of states are prefixed
+// with stateNode, so a state of any name is not taken for the start
+const (
+	startNode = "start"
+	stateNode = "state:"
+)
+
+// writeDOT renders the transition table as a Graphviz digraph: states are nodes,
+// transitions are edges labeled with the guard and the action
+func writeDOT(w io.Writer, transitions []models.Transition) error {
+	var b strings.Builder
+	b.WriteString("digraph transitions {\n")
+	fmt.Fprintf(&b, "\t%q [shape=point];\n", startNode)
+
+	// states are declared in the order they appear in the table
+	declared := make(map[string]bool)
+	for _, tr := range transitions {
+		for _, state := range []string{tr.From, tr.Event} {
+			if state == "" || declared[state] {
+				continue
+			}
+			declared[state] = true
+			fmt.Fprintf(&b, "\t%q [label=%q];\n", stateNode+state, state)
+		}
+	}
+
+	for _, tr := range transitions {
+		from := startNode
+		if tr.From != "" {
+			from = stateNode + tr.From
+		}
+
+		label := tr.Action
+		if tr.Guard != "" {
+			label = "[" + tr.Guard + "] " + label
+		}
+		fmt.Fprintf(&b, "\t%q -> %q [label=%q];\n", from, stateNode+tr.Event, label)
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+func TestWriteDOT(t *testing.T) {
+	transitions := []models.Transition{
+		{From: "", Event: models.Created, Action: models.ActionCreate},
+		{From: models.MessageSent, Event: models.Approved, Guard: models.GuardSameApprover, Action: models.ActionAccumulateDelay},
+		// a state named start is not the entry point
+		{From: models.Created, Event: "start", Action: models.ActionUpdate},
+	}
+
+	var b strings.Builder
+	if err := writeDOT(&b, transitions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `digraph transitions {
+	"start" [shape=point];
+	"state:CREATED" [label="CREATED"];
+	"state:MESSAGE_SENT" [label="MESSAGE_SENT"];
+	"state:APPROVED" [label="APPROVED"];
+	"state:start" [label="start"];
+	"start" -> "state:CREATED" [label="create"];
+	"state:MESSAGE_SENT" -> "state:APPROVED" [label="[same_approver] accumulate_delay"];
+	"state:CREATED" -> "state:start" [label="update"];
+}
+`
+	if b.String() != expected {
+		t.Fatalf("wrong graph, expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
@@ -2,9 +2,14 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi"
+	"github.com/seggga/approve-analytics/internal/domain/deadletter"
+	"github.com/seggga/approve-analytics/internal/domain/models"
 )
 
 // Handlers ...
@@ -14,6 +19,17 @@ func (s *Server) Handlers() http.Handler {
 		h.Use(s.CheckAuth)
 		h.Get("/totals", s.totals)
 		h.Get("/delays", s.delays)
+		h.Get("/delays/stats", s.delayStats)
+		h.Get("/timeseries", s.timeSeries)
+		h.Get("/tasks/pending", s.pending)
+		h.Get("/tasks/{id}", s.task)
+		h.Get("/tasks/{id}/history", s.history)
+		h.Get("/approvers", s.approvers)
+		h.Get("/approvers/{email}", s.approver)
+		h.Get("/rejected", s.rejected)
+		h.Post("/rejected/{id}/replay", s.replay)
+		h.Get("/sla/breaches", s.slaBreaches)
+		h.Get("/transitions", s.transitions)
 	})
 
 	return h
@@ -27,16 +43,17 @@ func (s *Server) Handlers() http.Handler {
 // @Produce json
 // @Success 200 {object} models.Totals true "finished and declined task counters"
 // @Failure 500 {string} string "internal error"
+// @Failure 503 {string} string "storage is unavailable"
 // @Router /totals [get]
 func (s *Server) totals(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debug("totals handler called")
 
-	totals, _, err := s.an.GetAggregates(r.Context())
+	totals, err := s.an.GetTotals(r.Context())
 	if err != nil {
-		s.logger.Sugar().Debugf("error getting aggregates %v", err)
+		s.logger.Sugar().Debugf("error getting totals %v", err)
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
@@ -52,29 +69,469 @@ func (s *Server) totals(w http.ResponseWriter, r *http.Request) {
 // @ID delays
 // @tags analytics
 // @Summary Get delays
-// @Description Get delays on all finished and declined tasks
+// @Description Get delays on finished and declined tasks ordered by task id.
+// @Description Pass the X-Next-Cursor header value as cursor to get the next page.
 // @Security Auth
 // @Produce json
+// @Param from query string false "tasks finished since, RFC3339"
+// @Param to query string false "tasks finished before, RFC3339"
+// @Param limit query int false "page size, 100 by default, 1000 at most"
+// @Param cursor query int false "last task id of the previous page"
+// @Param order query string false "asc (default) or desc" Enums(asc, desc)
 // @Success 200 {array} models.Delay true "task id and lag"
+// @Header 200 {string} X-Next-Cursor "cursor of the next page, absent on the last page"
+// @Failure 400 {string} string "bad parameters"
 // @Failure 500 {string} string "internal error"
+// @Failure 503 {string} string "storage is unavailable"
 // @Router /delays [get]
 func (s *Server) delays(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debug("delays handler called")
 
-	_, delays, err := s.an.GetAggregates(r.Context())
+	filter, err := parseDelayFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, delays, err := s.an.GetAggregates(r.Context(), filter)
 	if err != nil {
 		s.logger.Sugar().Debugf("error getting aggregates %v", err)
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
 	s.logger.Sugar().Debugf("got delays: %v", delays)
 
+	if uint64(len(delays)) == filter.Limit {
+		w.Header().Set(nextCursorHeader, strconv.FormatUint(delays[len(delays)-1].ID, 10))
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(delays)
 
 	return
 }
+
+// @ID delayStats
+// @tags analytics
+// @Summary Get delay statistics
+// @Description Get min, max, mean, median, 90, 95 and 99 percentiles and a histogram of delays
+// @Description on finished and declined tasks
+// @Security Auth
+// @Produce json
+// @Param type query string false "final state of tasks of the transition table, any by default"
+// @Param from query string false "tasks finished since, RFC3339"
+// @Param to query string false "tasks finished before, RFC3339"
+// @Param buckets query int false "number of histogram buckets, 10 by default, 100 at most"
+// @Success 200 {object} models.DelayStats true "delay statistics"
+// @Failure 400 {string} string "bad parameters"
+// @Failure 500 {string} string "internal error"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /delays/stats [get]
+func (s *Server) delayStats(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("delay stats handler called")
+
+	filter, err := parseStatsFilter(r, models.FinalStates(s.an.GetTransitions(r.Context())))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.an.GetDelayStats(r.Context(), filter)
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting delay statistics %v", err)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	s.logger.Sugar().Debugf("got delay statistics: %v", stats)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @ID timeseries
+// @tags analytics
+// @Summary Get time series
+// @Description Get numbers of finished or declined tasks or their average lag bucketed by the time tasks
+// @Description have reached the final state. Buckets start at the beginning of a day, a week (Monday) or a month
+// @Description in the tz time zone, buckets without tasks are omitted. avg_lag is given in nanoseconds.
+// @Security Auth
+// @Produce json
+// @Param metric query string true "finished, declined or avg_lag" Enums(finished, declined, avg_lag)
+// @Param interval query string false "day (default), week or month" Enums(day, week, month)
+// @Param tz query string false "IANA time zone name, UTC by default"
+// @Param from query string false "tasks finished since, RFC3339"
+// @Param to query string false "tasks finished before, RFC3339"
+// @Success 200 {array} models.Point true "time series"
+// @Failure 400 {string} string "bad parameters"
+// @Failure 500 {string} string "internal error"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /timeseries [get]
+func (s *Server) timeSeries(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("time series handler called")
+
+	filter, err := parseSeriesFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	points, err := s.an.GetTimeSeries(r.Context(), filter)
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting time series %v", err)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	s.logger.Sugar().Debugf("got %d time series points", len(points))
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(points)
+}
+
+// @ID pending
+// @tags analytics
+// @Summary Get pending tasks
+// @Description Get counters of tasks still in CREATED, MESSAGE_SENT or APPROVED state.
+// @Description Ages are measured from the last event of every task and given in nanoseconds.
+// @Security Auth
+// @Produce json
+// @Success 200 {array} models.PendingState true "pending tasks by state"
+// @Failure 500 {string} string "internal error"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /tasks/pending [get]
+func (s *Server) pending(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("pending handler called")
+
+	pending, err := s.an.GetPending(r.Context())
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting pending tasks %v", err)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	s.logger.Sugar().Debugf("got pending tasks: %v", pending)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pending)
+}
+
+// @ID task
+// @tags analytics
+// @Summary Get task state
+// @Description Get the current state, approver and accumulated lag of the task
+// @Security Auth
+// @Produce json
+// @Param id path int true "task id"
+// @Success 200 {object} models.TaskState true "task state"
+// @Failure 400 {string} string "bad task id"
+// @Failure 404 {string} string "task not found"
+// @Failure 500 {string} string "internal error"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /tasks/{id} [get]
+func (s *Server) task(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("task handler called")
+
+	taskID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad task id", http.StatusBadRequest)
+		return
+	}
+
+	state, err := s.an.GetTask(r.Context(), taskID)
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting task state %v", err)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	if state == nil {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+
+	s.logger.Sugar().Debugf("got task state: %v", state)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(state)
+}
+
+// @ID history
+// @tags analytics
+// @Summary Get task history
+// @Description Get all events stored for the task in the order they have been received
+// @Security Auth
+// @Produce json
+// @Param id path int true "task id"
+// @Success 200 {array} models.Event true "task events"
+// @Failure 400 {string} string "bad task id"
+// @Failure 404 {string} string "task not found"
+// @Failure 500 {string} string "internal error"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /tasks/{id}/history [get]
+func (s *Server) history(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("history handler called")
+
+	taskID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad task id", http.StatusBadRequest)
+		return
+	}
+
+	events, err := s.an.GetHistory(r.Context(), taskID)
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting history %v", err)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	if len(events) == 0 {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+
+	s.logger.Sugar().Debugf("got history: %v", events)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(events)
+}
+
+// @ID approvers
+// @tags analytics
+// @Summary Get approvers statistics
+// @Description Get approved, declined and pending counters and response lags of every approver
+// @Security Auth
+// @Produce json
+// @Success 200 {array} models.ApproverStats true "approvers statistics"
+// @Failure 500 {string} string "internal error"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /approvers [get]
+func (s *Server) approvers(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("approvers handler called")
+
+	stats, err := s.an.GetApprovers(r.Context())
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting approvers statistics %v", err)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	s.logger.Sugar().Debugf("got approvers statistics: %v", stats)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @ID approver
+// @tags analytics
+// @Summary Get approver statistics
+// @Description Get approved, declined and pending counters and response lags of the approver
+// @Security Auth
+// @Produce json
+// @Param email path string true "approver email"
+// @Success 200 {object} models.ApproverStats true "approver statistics"
+// @Failure 404 {string} string "approver not found"
+// @Failure 500 {string} string "internal error"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /approvers/{email} [get]
+func (s *Server) approver(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("approver handler called")
+
+	stats, err := s.an.GetApprover(r.Context(), chi.URLParam(r, "email"))
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting approver statistics %v", err)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	if stats == nil {
+		http.Error(w, "approver not found", http.StatusNotFound)
+		return
+	}
+
+	s.logger.Sugar().Debugf("got approver statistics: %v", stats)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @ID rejected
+// @tags dead-letter
+// @Summary Get rejected messages
+// @Description Get messages that failed parsing or processing and have not been replayed yet, ordered by id.
+// @Description Pass the X-Next-Cursor header value as cursor to get the next page.
+// @Security Auth
+// @Produce json
+// @Param limit query int false "page size, 100 by default, 1000 at most"
+// @Param cursor query int false "last message id of the previous page"
+// @Success 200 {array} models.RejectedMessage true "rejected messages"
+// @Header 200 {string} X-Next-Cursor "cursor of the next page, absent on the last page"
+// @Failure 400 {string} string "bad parameters"
+// @Failure 500 {string} string "internal error"
+// @Failure 501 {string} string "dead-letter store is not configured"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /rejected [get]
+func (s *Server) rejected(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("rejected handler called")
+
+	if s.dl == nil {
+		http.Error(w, "dead-letter store is not configured", http.StatusNotImplemented)
+		return
+	}
+
+	cursor, limit, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	messages, err := s.dl.ListRejected(r.Context(), cursor, limit)
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting rejected messages %v", err)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	if uint64(len(messages)) == limit {
+		w.Header().Set(nextCursorHeader, strconv.FormatUint(messages[len(messages)-1].ID, 10))
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(messages)
+}
+
+// @ID replay
+// @tags dead-letter
+// @Summary Replay rejected message
+// @Description Process the rejected message once again, on success it is not listed anymore
+// @Security Auth
+// @Param id path int true "rejected message id"
+// @Success 204 "message has been processed"
+// @Failure 400 {string} string "bad message id"
+// @Failure 404 {string} string "message not found"
+// @Failure 409 {string} string "message has already been replayed"
+// @Failure 422 {string} string "message has been rejected again"
+// @Failure 500 {string} string "internal error"
+// @Failure 501 {string} string "dead-letter store is not configured"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /rejected/{id}/replay [post]
+func (s *Server) replay(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("replay handler called")
+
+	if s.dl == nil {
+		http.Error(w, "dead-letter store is not configured", http.StatusNotImplemented)
+		return
+	}
+
+	ID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "bad message id", http.StatusBadRequest)
+		return
+	}
+
+	err = s.dl.Replay(r.Context(), ID)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, deadletter.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, deadletter.ErrReplayed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		s.logger.Sugar().Debugf("error replaying message %d: %v", ID, err)
+		http.Error(w, err.Error(), errorStatus(err))
+	}
+}
+
+// @ID slaBreaches
+// @tags sla
+// @Summary Get SLA breaches
+// @Description Get tasks waiting for the approver longer than allowed by the SLA policy, the longest waiting go first.
+// @Description Waited and limit are given in nanoseconds.
+// @Security Auth
+// @Produce json
+// @Success 200 {array} models.Breach true "overdue tasks"
+// @Failure 500 {string} string "internal error"
+// @Failure 501 {string} string "SLA policy is not configured"
+// @Failure 503 {string} string "storage is unavailable"
+// @Router /sla/breaches [get]
+func (s *Server) slaBreaches(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("SLA breaches handler called")
+
+	if s.sla == nil {
+		http.Error(w, "SLA policy is not configured", http.StatusNotImplemented)
+		return
+	}
+
+	breaches, err := s.sla.Breaches(r.Context())
+	if err != nil {
+		s.logger.Sugar().Debugf("error getting SLA breaches %v", err)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	s.logger.Sugar().Debugf("got %d SLA breaches", len(breaches))
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(breaches)
+}
+
+// @ID transitions
+// @tags analytics
+// @Summary Get task state machine
+// @Description Get the transition table the events are applied with. An empty from state means a new task.
+// @Description The dot format renders the table as a Graphviz digraph.
+// @Security Auth
+// @Produce json
+// @Produce text/vnd.graphviz
+// @Param format query string false "json (default) or dot" Enums(json, dot)
+// @Success 200 {array} models.Transition true "transition table"
+// @Failure 400 {string} string "bad format"
+// @Router /transitions [get]
+func (s *Server) transitions(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("transitions handler called")
+
+	transitions := s.an.GetTransitions(r.Context())
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(transitions)
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		writeDOT(w, transitions)
+	default:
+		http.Error(w, fmt.Sprintf("bad format parameter %q: expected json or dot", format), http.StatusBadRequest)
+	}
+}
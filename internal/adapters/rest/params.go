@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+const (
+	defaultBuckets = 10
+	maxBuckets     = 100
+
+	// nextCursorHeader holds a cursor to request the next page with
+	nextCursorHeader = "X-Next-Cursor"
+)
+
+// parseDelayFilter reads from, to, limit, cursor and order query parameters.
+// from and to are RFC3339 timestamps, order is either asc or desc
+func parseDelayFilter(r *http.Request) (*models.DelayFilter, error) {
+	q := r.URL.Query()
+	filter := &models.DelayFilter{}
+
+	var err error
+	if filter.From, filter.To, err = parsePeriod(q); err != nil {
+		return nil, err
+	}
+
+	if filter.Cursor, filter.Limit, err = parsePage(r); err != nil {
+		return nil, err
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return nil, fmt.Errorf("bad order parameter %q: expected asc or desc", q.Get("order"))
+	}
+
+	if err = filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// parseStatsFilter reads type, from, to and buckets query parameters.
+// type is one of final states of tasks, from and to are RFC3339 timestamps
+func parseStatsFilter(r *http.Request, final []string) (*models.StatsFilter, error) {
+	q := r.URL.Query()
+	filter := &models.StatsFilter{
+		Buckets: defaultBuckets,
+	}
+
+	var err error
+	if filter.From, filter.To, err = parsePeriod(q); err != nil {
+		return nil, err
+	}
+
+	if v := q.Get("type"); v != "" {
+		for _, state := range final {
+			if v == state {
+				filter.EventType = v
+			}
+		}
+		if filter.EventType == "" {
+			return nil, fmt.Errorf("bad type parameter %q: expected one of %s", v, strings.Join(final, ", "))
+		}
+	}
+
+	if v := q.Get("buckets"); v != "" {
+		filter.Buckets, err = strconv.ParseUint(v, 10, 64)
+		if err != nil || filter.Buckets == 0 || filter.Buckets > maxBuckets {
+			return nil, fmt.Errorf("bad buckets parameter %q: expected a number from 1 to %d", v, maxBuckets)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseSeriesFilter reads interval, metric, tz, from and to query parameters.
+// interval is day by default, tz is an IANA time zone name, UTC by default
+func parseSeriesFilter(r *http.Request) (*models.SeriesFilter, error) {
+	q := r.URL.Query()
+	filter := &models.SeriesFilter{
+		Interval: models.IntervalDay,
+		Location: time.UTC,
+	}
+
+	var err error
+	if filter.From, filter.To, err = parsePeriod(q); err != nil {
+		return nil, err
+	}
+
+	switch v := q.Get("interval"); v {
+	case "":
+	case models.IntervalDay, models.IntervalWeek, models.IntervalMonth:
+		filter.Interval = v
+	default:
+		return nil, fmt.Errorf("bad interval parameter %q: expected %s, %s or %s", v, models.IntervalDay, models.IntervalWeek, models.IntervalMonth)
+	}
+
+	switch v := q.Get("metric"); v {
+	case models.MetricFinished, models.MetricDeclined, models.MetricAvgLag:
+		filter.Metric = v
+	default:
+		return nil, fmt.Errorf("bad metric parameter %q: expected %s, %s or %s", v, models.MetricFinished, models.MetricDeclined, models.MetricAvgLag)
+	}
+
+	if v := q.Get("tz"); v != "" {
+		if filter.Location, err = time.LoadLocation(v); err != nil {
+			return nil, fmt.Errorf("bad tz parameter %q: %v", v, err)
+		}
+	}
+
+	return filter, nil
+}
+
+// parsePeriod reads from and to query parameters as RFC3339 timestamps
+func parsePeriod(q url.Values) (from, to time.Time, err error) {
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("bad from parameter %q: %v", v, err)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("bad to parameter %q: %v", v, err)
+		}
+	}
+
+	return from, to, models.CheckPeriod(from, to)
+}
+
+// parsePage reads limit and cursor query parameters
+func parsePage(r *http.Request) (cursor, limit uint64, err error) {
+	q := r.URL.Query()
+	limit = models.DefaultLimit
+
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.ParseUint(v, 10, 64)
+		if err != nil || limit == 0 || limit > models.MaxLimit {
+			return 0, 0, fmt.Errorf("bad limit parameter %q: expected a number from 1 to %d", v, models.MaxLimit)
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		if cursor, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("bad cursor parameter %q: %v", v, err)
+		}
+	}
+
+	return cursor, limit, nil
+}
@@ -0,0 +1,194 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+func TestParseDelayFilter(t *testing.T) {
+	from := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected *models.DelayFilter
+		wantErr  bool
+	}{
+		{
+			name:     "defaults",
+			query:    "",
+			expected: &models.DelayFilter{Limit: models.DefaultLimit},
+		},
+		{
+			name:     "all parameters",
+			query:    "?from=2022-08-01T00:00:00Z&to=2022-09-01T00:00:00Z&limit=10&cursor=42&order=desc",
+			expected: &models.DelayFilter{From: from, To: to, Limit: 10, Cursor: 42, Desc: true},
+		},
+		{
+			name:    "bad timestamp",
+			query:   "?from=yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "reversed range",
+			query:   "?from=2022-09-01T00:00:00Z&to=2022-08-01T00:00:00Z",
+			wantErr: true,
+		},
+		{
+			name:    "limit too big",
+			query:   "?limit=100000",
+			wantErr: true,
+		},
+		{
+			name:    "bad order",
+			query:   "?order=random",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/delays"+tt.query, nil)
+			filter, err := parseDelayFilter(req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got filter %v", filter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(filter, tt.expected) {
+				t.Fatalf("wrong filter: expected %v, got %v", *tt.expected, *filter)
+			}
+		})
+	}
+}
+
+func TestParseStatsFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		final    []string
+		expected *models.StatsFilter
+		wantErr  bool
+	}{
+		{
+			name:     "defaults",
+			query:    "",
+			expected: &models.StatsFilter{Buckets: defaultBuckets},
+		},
+		{
+			name:     "event type and buckets",
+			query:    "?type=DECLINED&buckets=5",
+			expected: &models.StatsFilter{EventType: models.Declined, Buckets: 5},
+		},
+		{
+			name:    "not a final state",
+			query:   "?type=APPROVED",
+			wantErr: true,
+		},
+		{
+			name:     "final state of a custom table",
+			query:    "?type=REASSIGNED",
+			final:    []string{models.Finished, "REASSIGNED"},
+			expected: &models.StatsFilter{EventType: "REASSIGNED", Buckets: defaultBuckets},
+		},
+		{
+			name:    "zero buckets",
+			query:   "?buckets=0",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/delays/stats"+tt.query, nil)
+			final := tt.final
+			if final == nil {
+				final = []string{models.Declined, models.Finished, models.Deleted}
+			}
+			filter, err := parseStatsFilter(req, final)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got filter %v", filter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(filter, tt.expected) {
+				t.Fatalf("wrong filter: expected %v, got %v", *tt.expected, *filter)
+			}
+		})
+	}
+}
+
+func TestParseSeriesFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected *models.SeriesFilter
+		wantErr  bool
+	}{
+		{
+			name:     "defaults",
+			query:    "?metric=finished",
+			expected: &models.SeriesFilter{Interval: models.IntervalDay, Metric: models.MetricFinished, Location: time.UTC},
+		},
+		{
+			name:  "all parameters",
+			query: "?metric=avg_lag&interval=week&tz=Europe/Moscow&from=2022-08-01T00:00:00Z",
+			expected: &models.SeriesFilter{
+				Interval: models.IntervalWeek,
+				Metric:   models.MetricAvgLag,
+				Location: time.FixedZone("Europe/Moscow", 0),
+				From:     time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "no metric",
+			query:   "",
+			wantErr: true,
+		},
+		{
+			name:    "bad interval",
+			query:   "?metric=declined&interval=hour",
+			wantErr: true,
+		},
+		{
+			name:    "bad time zone",
+			query:   "?metric=declined&tz=Mars/Olympus",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/timeseries"+tt.query, nil)
+			filter, err := parseSeriesFilter(req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got filter %v", filter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if filter.Interval != tt.expected.Interval || filter.Metric != tt.expected.Metric ||
+				filter.Location.String() != tt.expected.Location.String() ||
+				!filter.From.Equal(tt.expected.From) || !filter.To.Equal(tt.expected.To) {
+				t.Fatalf("wrong filter: expected %v, got %v", *tt.expected, *filter)
+			}
+		})
+	}
+}
@@ -33,16 +33,20 @@ type Server struct {
 	server   *http.Server
 	logger   *zap.Logger
 	an       ports.Analyter
+	dl       ports.DeadLetterer
+	sla      ports.SLAMonitor
 	listener net.Listener
 }
 
-// New ...
-func New(logger *zap.Logger, auth ports.Auther, an ports.Analyter, port string) *Server {
+// New creates a REST server, nil dl disables dead-letter endpoints, nil sla disables SLA endpoints
+func New(logger *zap.Logger, auth ports.Auther, an ports.Analyter, dl ports.DeadLetterer, sla ports.SLAMonitor, port string) *Server {
 	var err error
 	s := &Server{
 		auth:   auth,
 		logger: logger,
 		an:     an,
+		dl:     dl,
+		sla:    sla,
 	}
 
 	s.listener, err = net.Listen("tcp", ":"+port)
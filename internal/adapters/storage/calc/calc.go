@@ -0,0 +1,96 @@
+// Package calc computes statistics in Go for storages lacking them in SQL,
+// results match the ones calculated by the postgres store
+package calc
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// DelayStats calculates statistics and a histogram of buckets on lags given in seconds,
+// lags have to be sorted
+func DelayStats(lags []float64, buckets uint64) *models.DelayStats {
+	stats := &models.DelayStats{
+		Count:     uint64(len(lags)),
+		Histogram: make([]models.Bucket, 0, buckets),
+	}
+	if len(lags) == 0 {
+		return stats
+	}
+
+	min, max := lags[0], lags[len(lags)-1]
+	stats.Min, stats.Max, stats.Mean = Seconds(min), Seconds(max), Seconds(Mean(lags))
+	stats.Median, stats.P90 = Seconds(Percentile(lags, 0.5)), Seconds(Percentile(lags, 0.9))
+	stats.P95, stats.P99 = Seconds(Percentile(lags, 0.95)), Seconds(Percentile(lags, 0.99))
+
+	if buckets == 0 {
+		return stats
+	}
+	// all delays are equal, there is nothing to split
+	if min == max {
+		stats.Histogram = append(stats.Histogram, models.Bucket{From: stats.Min, To: stats.Max, Count: stats.Count})
+		return stats
+	}
+
+	width := (max - min) / float64(buckets)
+	for i := uint64(0); i < buckets; i++ {
+		stats.Histogram = append(stats.Histogram, models.Bucket{
+			From: Seconds(min + width*float64(i)),
+			To:   Seconds(min + width*float64(i+1)),
+		})
+	}
+	stats.Histogram[buckets-1].To = stats.Max
+
+	// like width_bucket, max is moved from an extra bucket to the last one
+	for _, lag := range lags {
+		b := uint64((lag - min) / (max - min) * float64(buckets))
+		if b >= buckets {
+			b = buckets - 1
+		}
+		stats.Histogram[b].Count++
+	}
+
+	return stats
+}
+
+// Truncate returns the beginning of the day, the week (Monday) or the month of t in its location
+func Truncate(t time.Time, interval string) (time.Time, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch interval {
+	case models.IntervalDay:
+		return day, nil
+	case models.IntervalWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	case models.IntervalMonth:
+		return day.AddDate(0, 0, 1-day.Day()), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown time series interval %s", interval)
+	}
+}
+
+// Mean calculates the average of values
+func Mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Percentile interpolates between sorted values like percentile_cont does
+func Percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[lower+1]-sorted[lower])*(pos-float64(lower))
+}
+
+// Seconds converts seconds to time.Duration rounding to nanoseconds
+func Seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
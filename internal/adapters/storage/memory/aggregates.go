@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/adapters/storage/calc"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// GetTotals reads numbers of finished and declined tasks maintained by writers
+func (s *Store) GetTotals(ctx context.Context) (*models.Totals, error) {
+	defer s.rlock()()
+
+	return &models.Totals{Finished: uint64(s.finished), Declined: uint64(s.declined)}, nil
+}
+
+// GetAggregates extracts statistics about finished and declined tasks and its delay.
+// Delays of tasks in filter.Final states are filtered and paginated by the filter, nil filter selects no delays
+func (s *Store) GetAggregates(ctx context.Context, filter *models.DelayFilter) (*models.Totals, []models.Delay, error) {
+	defer s.rlock()()
+
+	totals := &models.Totals{Finished: uint64(s.finished), Declined: uint64(s.declined)}
+	if filter == nil {
+		filter = &models.DelayFilter{}
+	}
+
+	final := s.final(filter.Final, "", filter.From, filter.To)
+	sort.Slice(final, func(i, j int) bool {
+		if filter.Desc {
+			return final[i].TaskID > final[j].TaskID
+		}
+		return final[i].TaskID < final[j].TaskID
+	})
+
+	delays := make([]models.Delay, 0)
+	for _, evt := range final {
+		if filter.Cursor != 0 && (!filter.Desc && evt.TaskID <= filter.Cursor || filter.Desc && evt.TaskID >= filter.Cursor) {
+			continue
+		}
+		if filter.Limit != 0 && uint64(len(delays)) == filter.Limit {
+			break
+		}
+		delays = append(delays, models.Delay{ID: evt.TaskID, Lag: evt.TotalDelay})
+	}
+
+	return totals, delays, nil
+}
+
+// final returns last events of tasks in one of final states. eventType narrows down the final state,
+// from and to bound the time the state is reached. The read lock has to be held
+func (s *Store) final(final []string, eventType string, from, to time.Time) []models.Event {
+	events := make([]models.Event, 0)
+	for _, evt := range s.inStates(final) {
+		if eventType != "" && evt.EventType != eventType ||
+			!from.IsZero() && evt.RecievedAt.Before(from) ||
+			!to.IsZero() && !evt.RecievedAt.Before(to) {
+			continue
+		}
+		events = append(events, evt)
+	}
+	return events
+}
+
+// set makes a lookup table of states
+func set(states []string) map[string]bool {
+	in := make(map[string]bool, len(states))
+	for _, state := range states {
+		in[state] = true
+	}
+	return in
+}
+
+// inStates returns last events of tasks in one of states. The read lock has to be held
+func (s *Store) inStates(states []string) []models.Event {
+	in := set(states)
+
+	events := make([]models.Event, 0)
+	for _, evt := range s.current() {
+		if in[evt.EventType] {
+			events = append(events, evt)
+		}
+	}
+	return events
+}
+
+// DelayStats calculates statistics and a histogram on delays of tasks in a final state
+func (s *Store) DelayStats(ctx context.Context, filter *models.StatsFilter) (*models.DelayStats, error) {
+	defer s.rlock()()
+
+	if filter == nil {
+		filter = &models.StatsFilter{}
+	}
+
+	final := s.final(filter.Final, filter.EventType, filter.From, filter.To)
+	lags := make([]float64, 0, len(final))
+	for _, evt := range final {
+		lags = append(lags, evt.TotalDelay.Seconds())
+	}
+	sort.Float64s(lags)
+
+	return calc.DelayStats(lags, filter.Buckets), nil
+}
+
+// ApproverStats extracts response statistics grouped by approver,
+// empty email means all approvers
+func (s *Store) ApproverStats(ctx context.Context, email string, states models.ResponseStates) ([]models.ApproverStats, error) {
+	defer s.rlock()()
+
+	byEmail := make(map[string]*models.ApproverStats)
+	lags := make(map[string][]float64)
+	get := func(approver string) *models.ApproverStats {
+		st, ok := byEmail[approver]
+		if !ok {
+			st = &models.ApproverStats{Email: approver}
+			byEmail[approver] = st
+		}
+		return st
+	}
+
+	approved, declined := set(states.Approved), set(states.Declined)
+	for _, evt := range s.events {
+		if email != "" && evt.Approver != email {
+			continue
+		}
+		switch {
+		case approved[evt.EventType]:
+			get(evt.Approver).Approved++
+		case declined[evt.EventType]:
+			get(evt.Approver).Declined++
+		default:
+			continue
+		}
+		lags[evt.Approver] = append(lags[evt.Approver], evt.Delay.Seconds())
+	}
+	for _, evt := range s.inStates(states.Awaiting) {
+		if email == "" || evt.Approver == email {
+			get(evt.Approver).Pending++
+		}
+	}
+
+	stats := make([]models.ApproverStats, 0, len(byEmail))
+	for approver, st := range byEmail {
+		if l := lags[approver]; len(l) != 0 {
+			sort.Float64s(l)
+			st.AvgLag, st.MedianLag, st.P95Lag = calc.Seconds(calc.Mean(l)), calc.Seconds(calc.Percentile(l, 0.5)), calc.Seconds(calc.Percentile(l, 0.95))
+		}
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Email < stats[j].Email
+	})
+
+	return stats, nil
+}
+
+// TimeSeries groups tasks in a final state into buckets truncated in the filter location,
+// buckets without tasks are omitted
+func (s *Store) TimeSeries(ctx context.Context, filter *models.SeriesFilter) ([]models.Point, error) {
+	defer s.rlock()()
+
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var final []models.Event
+	switch filter.Metric {
+	case models.MetricFinished:
+		final = s.final(filter.Final, models.Finished, filter.From, filter.To)
+	case models.MetricDeclined:
+		for _, evt := range s.final(filter.Final, "", filter.From, filter.To) {
+			if evt.EventType != models.Finished {
+				final = append(final, evt)
+			}
+		}
+	case models.MetricAvgLag:
+		final = s.final(filter.Final, "", filter.From, filter.To)
+	default:
+		return nil, fmt.Errorf("unknown time series metric %s", filter.Metric)
+	}
+
+	lags := make(map[time.Time][]float64)
+	for _, evt := range final {
+		start, err := calc.Truncate(evt.RecievedAt.In(loc), filter.Interval)
+		if err != nil {
+			return nil, err
+		}
+		lags[start] = append(lags[start], evt.TotalDelay.Seconds())
+	}
+
+	points := make([]models.Point, 0, len(lags))
+	for start, l := range lags {
+		p := models.Point{Start: start, Value: int64(len(l))}
+		if filter.Metric == models.MetricAvgLag {
+			p.Value = int64(calc.Seconds(calc.Mean(l)))
+		}
+		points = append(points, p)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Start.Before(points[j].Start)
+	})
+
+	return points, nil
+}
+
+// PendingStats counts tasks in every one of states, states without tasks are omitted
+func (s *Store) PendingStats(ctx context.Context, now time.Time, states []string) ([]models.PendingState, error) {
+	defer s.rlock()()
+
+	ages := make(map[string][]float64)
+	for _, evt := range s.inStates(states) {
+		ages[evt.EventType] = append(ages[evt.EventType], now.Sub(evt.RecievedAt).Seconds())
+	}
+
+	stats := make([]models.PendingState, 0, len(ages))
+	for _, state := range states {
+		a, ok := ages[state]
+		if !ok {
+			continue
+		}
+		sort.Float64s(a)
+		stats = append(stats, models.PendingState{
+			State:  state,
+			Count:  uint64(len(a)),
+			AvgAge: calc.Seconds(calc.Mean(a)),
+			MaxAge: calc.Seconds(a[len(a)-1]),
+		})
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// Reject stores a rejected message, msg.ID and msg.RejectedAt are set by the store
+func (s *Store) Reject(ctx context.Context, msg *models.RejectedMessage) error {
+	defer s.lock()()
+
+	msg.ID = uint64(len(s.rejected) + 1)
+	msg.RejectedAt = time.Now()
+	msg.ReplayedAt = nil
+	s.rejected = append(s.rejected, *msg)
+
+	return nil
+}
+
+// ListRejected extracts messages that have not been replayed yet with ID greater than cursor
+func (s *Store) ListRejected(ctx context.Context, cursor, limit uint64) ([]models.RejectedMessage, error) {
+	defer s.rlock()()
+
+	messages := make([]models.RejectedMessage, 0)
+	for _, msg := range s.rejected {
+		if uint64(len(messages)) == limit {
+			break
+		}
+		if msg.ReplayedAt == nil && msg.ID > cursor {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, nil
+}
+
+// GetRejected extracts a rejected message by its ID, nil means there is no such message
+func (s *Store) GetRejected(ctx context.Context, ID uint64) (*models.RejectedMessage, error) {
+	defer s.rlock()()
+
+	if ID == 0 || ID > uint64(len(s.rejected)) {
+		return nil, nil
+	}
+
+	msg := s.rejected[ID-1]
+	return &msg, nil
+}
+
+// MarkReplayed notes that the rejected message has been processed successfully
+func (s *Store) MarkReplayed(ctx context.Context, ID uint64) error {
+	defer s.lock()()
+
+	if ID == 0 || ID > uint64(len(s.rejected)) {
+		return nil
+	}
+
+	now := time.Now()
+	s.rejected[ID-1].ReplayedAt = &now
+	return nil
+}
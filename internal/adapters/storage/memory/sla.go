@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// breachKey identifies a breach, a task sent to the approver again may breach once more
+type breachKey struct {
+	taskID uint64
+	sentAt time.Time
+}
+
+// PendingApprovals extracts last events of tasks waiting in one of states since before sentBefore
+func (s *Store) PendingApprovals(ctx context.Context, sentBefore time.Time, states []string) ([]models.Event, error) {
+	defer s.rlock()()
+
+	events := make([]models.Event, 0)
+	for _, evt := range s.inStates(states) {
+		if evt.RecievedAt.Before(sentBefore) {
+			events = append(events, evt)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].RecievedAt.Before(events[j].RecievedAt)
+	})
+
+	return events, nil
+}
+
+// RecordBreaches stores breaches skipping the ones recorded before for the same task and sending time
+func (s *Store) RecordBreaches(ctx context.Context, breaches []models.Breach) ([]models.Breach, error) {
+	defer s.lock()()
+
+	recorded := make([]models.Breach, 0)
+	for _, b := range breaches {
+		key := breachKey{taskID: b.TaskID, sentAt: b.SentAt.UTC()}
+		if _, ok := s.breaches[key]; ok {
+			continue
+		}
+		s.breaches[key] = struct{}{}
+		recorded = append(recorded, b)
+	}
+
+	return recorded, nil
+}
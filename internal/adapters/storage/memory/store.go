@@ -0,0 +1,248 @@
+// Package memory keeps analytics in memory with the semantics of the postgres store.
+// Data is lost on restart, so the store is meant for tests and local demos
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+var (
+	_ ports.EventStorage    = &Store{}
+	_ ports.DeadLetterStore = &Store{}
+	_ ports.SLAStorage      = &Store{}
+)
+
+// Store is a thread-safe in-memory storage
+type Store struct {
+	*state
+
+	// undo is set on a store passed to Atomic callbacks, its methods run under the lock
+	// held by Atomic and record how to revert their changes
+	undo *[]func()
+}
+
+// state is shared by the store and stores passed to Atomic callbacks
+type state struct {
+	mu sync.RWMutex
+
+	// events is an append-only log, an event ID is its index plus one
+	events []models.Event
+	// tasks points to the index of the last event of every task
+	tasks      map[uint64]int
+	messageIDs map[string]struct{}
+	finished   int64
+	declined   int64
+
+	rejected []models.RejectedMessage
+	breaches map[breachKey]struct{}
+}
+
+// New creates an empty store
+func New() *Store {
+	return &Store{
+		state: &state{
+			tasks:      make(map[uint64]int),
+			messageIDs: make(map[string]struct{}),
+			breaches:   make(map[breachKey]struct{}),
+		},
+	}
+}
+
+// Init does nothing, the store needs no schema
+func (s *Store) Init(ctx context.Context) error {
+	return nil
+}
+
+// lock takes the write lock unless it is held by Atomic, the result releases it
+func (s *Store) lock() func() {
+	if s.undo != nil {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// rlock takes the read lock unless the write lock is held by Atomic, the result releases it
+func (s *Store) rlock() func() {
+	if s.undo != nil {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+// onRollback records a change to revert if the transaction fails
+func (s *Store) onRollback(fn func()) {
+	if s.undo != nil {
+		*s.undo = append(*s.undo, fn)
+	}
+}
+
+// Atomic runs fn holding the write lock, so transactions are applied one by one.
+// Changes made by fn are reverted if it returns an error, nested calls join the running transaction
+func (s *Store) Atomic(ctx context.Context, fn func(tx ports.EventStorage) error) error {
+	if s.undo != nil {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &Store{state: s.state, undo: &[]func(){}}
+	if err := fn(tx); err != nil {
+		for i := len(*tx.undo) - 1; i >= 0; i-- {
+			(*tx.undo)[i]()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Insert adds event about task that has not been stored yet
+func (s *Store) Insert(ctx context.Context, msg *models.Message) error {
+	defer s.lock()()
+
+	if _, ok := s.tasks[msg.TaskID]; ok {
+		return fmt.Errorf("%w: error inserting new event of task %d", ports.ErrTaskExists, msg.TaskID)
+	}
+
+	return s.appendEvent(msg, false)
+}
+
+// Select extracts the current state of a task with specified ID, nil means there is no such task
+func (s *Store) Select(ctx context.Context, taskID uint64) (*models.Event, error) {
+	defer s.rlock()()
+
+	i, ok := s.tasks[taskID]
+	if !ok {
+		return nil, nil
+	}
+
+	evt := s.events[i]
+	return &evt, nil
+}
+
+// Update appends an event about particular task with msg values, the accumulated delay is kept
+func (s *Store) Update(ctx context.Context, msg *models.Message) error {
+	defer s.lock()()
+
+	return s.appendEvent(msg, false)
+}
+
+// UpdateDelay appends an event about particular task with msg values and the delay
+// calculated since the previous event
+func (s *Store) UpdateDelay(ctx context.Context, msg *models.Message) error {
+	defer s.lock()()
+
+	return s.appendEvent(msg, true)
+}
+
+// appendEvent adds a new event to the task log and moves the task to it, with withDelay
+// the time passed since the previous event is added to the accumulated delay.
+// The write lock has to be held
+func (s *Store) appendEvent(msg *models.Message, withDelay bool) error {
+	if msg.ID != "" {
+		if _, ok := s.messageIDs[msg.ID]; ok {
+			return fmt.Errorf("error appending event: message %s already exists", msg.ID)
+		}
+	}
+
+	evt := models.Event{
+		ID:         uint64(len(s.events) + 1),
+		EventType:  msg.EventType,
+		TaskID:     msg.TaskID,
+		Approver:   msg.Approver,
+		RecievedAt: msg.RecievedAt,
+	}
+	prevIndex, hasPrev := s.tasks[msg.TaskID]
+	if hasPrev {
+		prev := s.events[prevIndex]
+		if withDelay {
+			evt.Delay = msg.RecievedAt.Sub(prev.RecievedAt)
+		}
+		evt.TotalDelay = prev.TotalDelay + evt.Delay
+	}
+
+	s.events = append(s.events, evt)
+	s.tasks[msg.TaskID] = len(s.events) - 1
+	if msg.ID != "" {
+		s.messageIDs[msg.ID] = struct{}{}
+	}
+
+	s.onRollback(func() {
+		s.events = s.events[:len(s.events)-1]
+		if hasPrev {
+			s.tasks[msg.TaskID] = prevIndex
+		} else {
+			delete(s.tasks, msg.TaskID)
+		}
+		delete(s.messageIDs, msg.ID)
+	})
+
+	return nil
+}
+
+// Seen reports whether an event with the message id has already been stored
+func (s *Store) Seen(ctx context.Context, messageID string) (bool, error) {
+	defer s.rlock()()
+
+	_, ok := s.messageIDs[messageID]
+	return ok, nil
+}
+
+// AddTotals changes counters of tasks in a final state
+func (s *Store) AddTotals(ctx context.Context, finished, declined int64) error {
+	defer s.lock()()
+
+	s.finished += finished
+	s.declined += declined
+	s.onRollback(func() {
+		s.finished -= finished
+		s.declined -= declined
+	})
+
+	return nil
+}
+
+// RecountTotals replaces counters by numbers of tasks in finished and in declined states
+func (s *Store) RecountTotals(ctx context.Context, finished, declined []string) error {
+	defer s.lock()()
+
+	prevFinished, prevDeclined := s.finished, s.declined
+	s.finished = int64(len(s.inStates(finished)))
+	s.declined = int64(len(s.inStates(declined)))
+	s.onRollback(func() {
+		s.finished, s.declined = prevFinished, prevDeclined
+	})
+
+	return nil
+}
+
+// History extracts all events of the task in the order they have been stored
+func (s *Store) History(ctx context.Context, taskID uint64) ([]models.Event, error) {
+	defer s.rlock()()
+
+	events := make([]models.Event, 0)
+	for _, evt := range s.events {
+		if evt.TaskID == taskID {
+			events = append(events, evt)
+		}
+	}
+
+	return events, nil
+}
+
+// current returns last events of all tasks, the read lock has to be held
+func (s *Store) current() []models.Event {
+	events := make([]models.Event, 0, len(s.tasks))
+	for _, i := range s.tasks {
+		events = append(events, s.events[i])
+	}
+	return events
+}
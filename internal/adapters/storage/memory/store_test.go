@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/adapters/storage/storagetest"
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+var timeStamp = time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) ports.EventStorage {
+		return New()
+	})
+}
+
+func TestAnalytics(t *testing.T) {
+	ctx := context.TODO()
+	store := New()
+	an := analytic.New(store)
+
+	msgs := []models.Message{
+		{EventType: models.Created, TaskID: 1, RecievedAt: timeStamp.Add(-100 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 1, Approver: "a@mail.com", RecievedAt: timeStamp.Add(-90 * time.Second)},
+		{EventType: models.Approved, TaskID: 1, Approver: "a@mail.com", RecievedAt: timeStamp.Add(-80 * time.Second)},
+		{EventType: models.Finished, TaskID: 1, RecievedAt: timeStamp.Add(-70 * time.Second)},
+		{EventType: models.Created, TaskID: 2, RecievedAt: timeStamp.Add(-100 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 2, Approver: "b@mail.com", RecievedAt: timeStamp.Add(-90 * time.Second)},
+		{EventType: models.Declined, TaskID: 2, Approver: "b@mail.com", RecievedAt: timeStamp.Add(-60 * time.Second)},
+		{EventType: models.Created, TaskID: 3, RecievedAt: timeStamp.Add(-50 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 3, Approver: "a@mail.com", RecievedAt: timeStamp.Add(-40 * time.Second)},
+	}
+	for _, v := range msgs {
+		if err := an.WriteEvent(ctx, &v); err != nil {
+			t.Fatalf("unexpected error on message %v: %v", v, err)
+		}
+	}
+
+	totals, delays, err := an.GetAggregates(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on getting aggregates: %v", err)
+	}
+	expectedDelays := []models.Delay{{ID: 1, Lag: 10 * time.Second}, {ID: 2, Lag: 30 * time.Second}}
+	if *totals != (models.Totals{Finished: 1, Declined: 1}) || !reflect.DeepEqual(delays, expectedDelays) {
+		t.Fatalf("wrong aggregates: %v, %v", *totals, delays)
+	}
+
+	_, delays, _ = an.GetAggregates(ctx, &models.DelayFilter{Cursor: 2, Desc: true, Limit: 1})
+	if !reflect.DeepEqual(delays, expectedDelays[:1]) {
+		t.Fatalf("wrong page of delays: %v", delays)
+	}
+
+	stats, err := an.GetDelayStats(ctx, &models.StatsFilter{Buckets: 2})
+	if err != nil {
+		t.Fatalf("unexpected error on getting delay statistics: %v", err)
+	}
+	if stats.Count != 2 || stats.Median != 20*time.Second || stats.P90 != 28*time.Second ||
+		!reflect.DeepEqual(stats.Histogram, []models.Bucket{
+			{From: 10 * time.Second, To: 20 * time.Second, Count: 1},
+			{From: 20 * time.Second, To: 30 * time.Second, Count: 1},
+		}) {
+		t.Fatalf("wrong delay statistics: %+v", *stats)
+	}
+
+	approver, err := an.GetApprover(ctx, "a@mail.com")
+	if err != nil {
+		t.Fatalf("unexpected error on getting approver statistics: %v", err)
+	}
+	if *approver != (models.ApproverStats{Email: "a@mail.com", Approved: 1, Pending: 1,
+		AvgLag: 10 * time.Second, MedianLag: 10 * time.Second, P95Lag: 10 * time.Second}) {
+		t.Fatalf("wrong approver statistics: %+v", *approver)
+	}
+
+	points, err := an.GetTimeSeries(ctx, &models.SeriesFilter{Interval: models.IntervalWeek, Metric: models.MetricAvgLag})
+	if err != nil {
+		t.Fatalf("unexpected error on getting time series: %v", err)
+	}
+	// 2022-08-01 is Monday
+	if !reflect.DeepEqual(points, []models.Point{{Start: time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC), Value: int64(20 * time.Second)}}) {
+		t.Fatalf("wrong time series: %v", points)
+	}
+
+	pending, err := store.PendingApprovals(ctx, timeStamp, []string{models.MessageSent})
+	if err != nil || len(pending) != 1 || pending[0].TaskID != 3 {
+		t.Fatalf("wrong pending approvals: %v, %v", pending, err)
+	}
+}
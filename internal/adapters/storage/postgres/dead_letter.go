@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+var _ ports.DeadLetterStore = &Store{}
+
+// Reject stores a rejected message, msg.ID and msg.RejectedAt are set by the database
+func (s *Store) Reject(ctx context.Context, msg *models.RejectedMessage) error {
+	query := `INSERT INTO analytics.rejected_messages (topic, kafka_partition, kafka_offset, payload, error)
+	VALUES ($1, $2, $3, $4, $5) RETURNING id, rejected_at`
+	err := s.Pool.QueryRow(ctx, query,
+		msg.Topic,
+		msg.Partition,
+		msg.Offset,
+		msg.Payload,
+		msg.Error,
+	).Scan(&msg.ID, &msg.RejectedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting rejected message in db: %v", err)
+	}
+
+	return nil
+}
+
+// ListRejected extracts messages that have not been replayed yet with ID greater than cursor
+func (s *Store) ListRejected(ctx context.Context, cursor, limit uint64) ([]models.RejectedMessage, error) {
+	query := `SELECT id, topic, kafka_partition, kafka_offset, payload, error, rejected_at, replayed_at
+	FROM analytics.rejected_messages WHERE replayed_at IS NULL AND id > $1 ORDER BY id LIMIT $2`
+	rows, err := s.Pool.Query(ctx, query, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting rejected messages: %v", err)
+	}
+	defer rows.Close()
+
+	messages := make([]models.RejectedMessage, 0)
+	for rows.Next() {
+		var msg models.RejectedMessage
+		err = rows.Scan(&msg.ID, &msg.Topic, &msg.Partition, &msg.Offset, &msg.Payload, &msg.Error, &msg.RejectedAt, &msg.ReplayedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error reading rejected messages: %v", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetRejected extracts a rejected message by its ID, nil means there is no such message
+func (s *Store) GetRejected(ctx context.Context, ID uint64) (*models.RejectedMessage, error) {
+	var msg models.RejectedMessage
+	query := `SELECT id, topic, kafka_partition, kafka_offset, payload, error, rejected_at, replayed_at
+	FROM analytics.rejected_messages WHERE id=$1`
+	err := s.Pool.QueryRow(ctx, query, ID).
+		Scan(&msg.ID, &msg.Topic, &msg.Partition, &msg.Offset, &msg.Payload, &msg.Error, &msg.RejectedAt, &msg.ReplayedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error selecting rejected message: %v", err)
+	}
+
+	return &msg, nil
+}
+
+// MarkReplayed notes that the rejected message has been processed successfully
+func (s *Store) MarkReplayed(ctx context.Context, ID uint64) error {
+	query := `UPDATE analytics.rejected_messages SET replayed_at=now() WHERE id=$1`
+	_, err := s.Pool.Exec(ctx, query, ID)
+	return err
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	// versionDDL creates a table keeping applied migrations. The table lives in the
+	// analytics schema, so dropping the schema resets the version as well
+	versionDDL = `
+	CREATE SCHEMA IF NOT EXISTS analytics;
+	CREATE TABLE IF NOT EXISTS analytics.schema_version
+	(
+		version INT4 NOT NULL,
+		name varchar(256) NOT NULL,
+		applied_at timestamp with time zone NOT NULL DEFAULT now(),
+
+		CONSTRAINT schema_version_pkey PRIMARY KEY (version)
+	);
+	`
+
+	// migrationLock is a key of the advisory lock held while migrations are applied,
+	// so several instances started at once do not run the same migration twice
+	migrationLock = 0x616e616c79746963
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is a pair of scripts changing the schema to the version and back
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// MigrateUp applies all pending migrations
+func (s *Store) MigrateUp(ctx context.Context) error {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return err
+	}
+
+	return s.migrate(ctx, migrations, len(migrations))
+}
+
+// MigrateDown rolls back the last steps migrations
+func (s *Store) MigrateDown(ctx context.Context, steps int) error {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return err
+	}
+
+	version, err := s.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	target := version - steps
+	if target < 0 {
+		target = 0
+	}
+
+	return s.migrate(ctx, migrations, target)
+}
+
+// Version returns the number of the last applied migration, 0 means an empty database
+func (s *Store) Version(ctx context.Context) (int, error) {
+	if _, err := s.Pool.Exec(ctx, versionDDL); err != nil {
+		return 0, fmt.Errorf("error creating schema version table: %v", err)
+	}
+
+	var version int
+	query := `SELECT COALESCE(max(version), 0) FROM analytics.schema_version;`
+	if err := s.Pool.QueryRow(ctx, query).Scan(&version); err != nil {
+		return 0, fmt.Errorf("error reading schema version: %v", err)
+	}
+
+	return version, nil
+}
+
+// migrate moves the schema to the target version applying or rolling back migrations
+// one by one, every migration is executed in a separate transaction
+func (s *Store) migrate(ctx context.Context, migrations []migration, target int) error {
+	conn, err := s.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("error acquiring connection: %v", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1);`, migrationLock); err != nil {
+		return fmt.Errorf("error acquiring migration lock: %v", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1);`, migrationLock)
+
+	if _, err := conn.Exec(ctx, versionDDL); err != nil {
+		return fmt.Errorf("error creating schema version table: %v", err)
+	}
+
+	var version int
+	query := `SELECT COALESCE(max(version), 0) FROM analytics.schema_version;`
+	if err := conn.QueryRow(ctx, query).Scan(&version); err != nil {
+		return fmt.Errorf("error reading schema version: %v", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version || m.version > target {
+			continue
+		}
+		err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, m.up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `INSERT INTO analytics.schema_version (version, name) VALUES ($1, $2);`, m.version, m.name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error applying migration %d %s: %v", m.version, m.name, err)
+		}
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version > version || m.version <= target {
+			continue
+		}
+		err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, m.down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, `DELETE FROM analytics.schema_version WHERE version=$1;`, m.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("error rolling back migration %d %s: %v", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// loadMigrations reads migrations from files named NNNN_name.up.sql and NNNN_name.down.sql.
+// Versions have to start from 1 and go without gaps, every version needs both scripts
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("error listing migrations: %v", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 || version < 1 {
+			return nil, fmt.Errorf("bad migration file name %s", file)
+		}
+
+		text, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %v", file, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: parts[1]}
+			byVersion[version] = m
+		}
+		if m.name != parts[1] {
+			return nil, fmt.Errorf("migration %d has different names: %s, %s", version, m.name, parts[1])
+		}
+
+		switch direction {
+		case ".up":
+			m.up = string(text)
+		case ".down":
+			m.down = string(text)
+		default:
+			return nil, fmt.Errorf("bad migration file name %s: expected .up.sql or .down.sql", file)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d %s needs both up and down scripts", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+	}
+
+	return migrations, nil
+}
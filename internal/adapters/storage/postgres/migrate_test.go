@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// baselineDDL is the schema created by Init of the version without migrations
+const baselineDDL = `
+	DROP SCHEMA IF EXISTS analytics CASCADE;
+	DROP TYPE IF EXISTS event_t CASCADE;
+
+	CREATE SCHEMA IF NOT EXISTS analytics;
+	CREATE TYPE event_t AS enum
+	(
+		'CREATED',
+		'MESSAGE_SENT',
+		'APPROVED',
+		'DECLINED',
+		'FINISHED',
+		'DELETED'
+	);
+	CREATE TABLE IF NOT EXISTS analytics.events
+	(
+		id serial4 NOT NULL,
+		task_id INT4 NOT NULL,
+		event_type event_t NOT NULL,
+		approver_email varchar(256) NOT NULL,
+		recieved_at timestamp with time zone NOT NULL,
+		total_delay interval SECOND DEFAULT NULL,
+
+		CONSTRAINT events_pkey PRIMARY KEY (id)
+	);
+	CREATE TABLE IF NOT EXISTS analytics.totals
+	(
+		id INT DEFAULT 0,
+		finished INT4,
+		declined INT4
+	);
+`
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("error loading embedded migrations: %v", err)
+	}
+	if len(migrations) == 0 || migrations[0].version != 1 {
+		t.Fatalf("embedded migrations have to start from version 1, got %v", migrations)
+	}
+
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr bool
+	}{
+		{
+			name: "ordered",
+			files: fstest.MapFS{
+				"migrations/0002_second.up.sql":   {Data: []byte("up 2")},
+				"migrations/0002_second.down.sql": {Data: []byte("down 2")},
+				"migrations/0001_first.up.sql":    {Data: []byte("up 1")},
+				"migrations/0001_first.down.sql":  {Data: []byte("down 1")},
+			},
+		},
+		{
+			name: "missing down",
+			files: fstest.MapFS{
+				"migrations/0001_first.up.sql": {Data: []byte("up 1")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "gap in versions",
+			files: fstest.MapFS{
+				"migrations/0001_first.up.sql":   {Data: []byte("up 1")},
+				"migrations/0001_first.down.sql": {Data: []byte("down 1")},
+				"migrations/0003_third.up.sql":   {Data: []byte("up 3")},
+				"migrations/0003_third.down.sql": {Data: []byte("down 3")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad name",
+			files: fstest.MapFS{
+				"migrations/first.up.sql": {Data: []byte("up 1")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", migrations)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(migrations) != 2 || migrations[0].up != "up 1" || migrations[1].down != "down 2" {
+				t.Fatalf("wrong migrations: %v", migrations)
+			}
+		})
+	}
+}
+
+// a database created by the version without migrations is upgraded keeping its tasks
+func TestMigrateFromBaseline(t *testing.T) {
+	ctx := context.TODO()
+	// the schema is created from scratch again for the following tests
+	defer func() {
+		_ = store.Drop(ctx)
+		_ = store.Init(ctx)
+	}()
+
+	sentAt := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	query := baselineDDL + `
+	INSERT INTO analytics.events (task_id, event_type, approver_email, recieved_at, total_delay) VALUES
+		(201, 'CREATED', '', '2022-08-01 11:00:00+00', NULL),
+		(201, 'MESSAGE_SENT', 'approver@mail.com', '2022-08-01 12:00:00+00', interval '0 second'),
+		(202, 'CREATED', '', '2022-08-01 11:00:00+00', NULL),
+		(202, 'FINISHED', '', '2022-08-01 11:30:00+00', interval '30 second');
+	INSERT INTO analytics.totals (id, finished, declined) VALUES (0, 1, 0), (0, 1, 0);`
+	if _, err := store.Pool.Exec(ctx, query); err != nil {
+		t.Fatalf("error creating baseline schema: %v", err)
+	}
+
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("error upgrading baseline schema: %v", err)
+	}
+
+	evt, err := store.Select(ctx, 201)
+	if err != nil || evt == nil || evt.EventType != models.MessageSent || !evt.RecievedAt.Equal(sentAt) {
+		t.Fatalf("stored task has been lost: %v, %v", evt, err)
+	}
+
+	// the upgraded schema accepts new events of stored and new tasks
+	msg := models.Message{EventType: models.Approved, TaskID: 201, Approver: "approver@mail.com", RecievedAt: sentAt.Add(time.Minute)}
+	if err := store.UpdateDelay(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on updating stored task: %v", err)
+	}
+	if evt, err = store.Select(ctx, 201); err != nil || evt.TotalDelay != time.Minute {
+		t.Fatalf("wrong delay of stored task: %v, %v", evt, err)
+	}
+	msg = models.Message{EventType: models.Created, TaskID: 203, RecievedAt: sentAt}
+	if err := store.Insert(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on inserting new task: %v", err)
+	}
+
+	totals, err := store.GetTotals(ctx)
+	if err != nil || *totals != (models.Totals{Finished: 1}) {
+		t.Fatalf("wrong totals of stored tasks: %v, %v", totals, err)
+	}
+}
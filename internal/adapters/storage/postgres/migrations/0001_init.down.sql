@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS analytics.totals;
+DROP TABLE IF EXISTS analytics.tasks;
+DROP TABLE IF EXISTS analytics.events;
+DROP TYPE IF EXISTS event_t;
@@ -0,0 +1,56 @@
+DO $$ BEGIN
+	CREATE TYPE event_t AS enum
+	(
+		'CREATED',
+		'MESSAGE_SENT',
+		'APPROVED',
+		'DECLINED',
+		'FINISHED',
+		'DELETED'
+	);
+EXCEPTION
+	WHEN duplicate_object THEN NULL;
+END $$;
+
+-- events is an append-only log, every accepted message adds a row
+-- delay is the lag caused by the event, total_delay is the lag accumulated by the task
+CREATE TABLE IF NOT EXISTS analytics.events
+(
+	id serial4 NOT NULL,
+	task_id INT4 NOT NULL,
+	event_type event_t NOT NULL,
+	approver_email varchar(256) NOT NULL,
+	recieved_at timestamp with time zone NOT NULL,
+	delay interval SECOND NOT NULL DEFAULT interval '0 second',
+	total_delay interval SECOND NOT NULL DEFAULT interval '0 second',
+
+	CONSTRAINT events_pkey PRIMARY KEY (id)
+);
+-- events created by versions without migrations have no delay and a nullable total_delay
+ALTER TABLE analytics.events ADD COLUMN IF NOT EXISTS delay interval SECOND NOT NULL DEFAULT interval '0 second';
+UPDATE analytics.events SET total_delay = interval '0 second' WHERE total_delay IS NULL;
+ALTER TABLE analytics.events
+	ALTER COLUMN total_delay SET DEFAULT interval '0 second',
+	ALTER COLUMN total_delay SET NOT NULL;
+CREATE INDEX IF NOT EXISTS events_task_id_idx ON analytics.events (task_id, id);
+
+-- tasks points to the last event of every task, so the current state
+-- of a task is always derived from the log
+CREATE TABLE IF NOT EXISTS analytics.tasks
+(
+	task_id INT4 NOT NULL,
+	event_id INT4 NOT NULL REFERENCES analytics.events (id),
+
+	CONSTRAINT tasks_pkey PRIMARY KEY (task_id)
+);
+-- tasks stored by versions without migrations point to their last events
+INSERT INTO analytics.tasks (task_id, event_id)
+SELECT task_id, max(id) FROM analytics.events GROUP BY task_id
+ON CONFLICT (task_id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS analytics.totals
+(
+	id INT DEFAULT 0,
+	finished INT4,
+	declined INT4
+);
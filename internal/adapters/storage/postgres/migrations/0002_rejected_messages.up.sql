@@ -0,0 +1,15 @@
+-- rejected_messages is a dead-letter queue of messages that failed parsing or processing
+CREATE TABLE IF NOT EXISTS analytics.rejected_messages
+(
+	id serial8 NOT NULL,
+	topic varchar(256) NOT NULL,
+	kafka_partition INT4 NOT NULL,
+	kafka_offset INT8 NOT NULL,
+	payload bytea NOT NULL,
+	error text NOT NULL,
+	rejected_at timestamp with time zone NOT NULL DEFAULT now(),
+	replayed_at timestamp with time zone DEFAULT NULL,
+
+	CONSTRAINT rejected_messages_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS rejected_messages_pending_idx ON analytics.rejected_messages (id) WHERE replayed_at IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS analytics.events_message_id_idx;
+ALTER TABLE analytics.events DROP COLUMN IF EXISTS message_id;
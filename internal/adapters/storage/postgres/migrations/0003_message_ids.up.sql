@@ -0,0 +1,3 @@
+-- message_id is an optional id given by the producer, a message with a known id is not stored twice
+ALTER TABLE analytics.events ADD COLUMN IF NOT EXISTS message_id varchar(256) DEFAULT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS events_message_id_idx ON analytics.events (message_id) WHERE message_id IS NOT NULL;
@@ -0,0 +1,14 @@
+-- sla_breaches keeps tasks detected waiting for the approver longer than allowed,
+-- a task sent to the approver again may breach once more
+CREATE TABLE IF NOT EXISTS analytics.sla_breaches
+(
+	id serial8 NOT NULL,
+	task_id INT8 NOT NULL,
+	approver_email varchar(256) NOT NULL,
+	sent_at timestamp with time zone NOT NULL,
+	sla_limit interval NOT NULL,
+	detected_at timestamp with time zone NOT NULL,
+
+	CONSTRAINT sla_breaches_pkey PRIMARY KEY (id),
+	CONSTRAINT sla_breaches_task_sent_key UNIQUE (task_id, sent_at)
+);
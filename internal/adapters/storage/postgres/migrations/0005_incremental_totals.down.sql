@@ -0,0 +1,9 @@
+ALTER TABLE analytics.totals
+	DROP CONSTRAINT IF EXISTS totals_pkey,
+	ALTER COLUMN id DROP NOT NULL,
+	ALTER COLUMN finished DROP NOT NULL,
+	ALTER COLUMN finished DROP DEFAULT,
+	ALTER COLUMN finished TYPE INT4,
+	ALTER COLUMN declined DROP NOT NULL,
+	ALTER COLUMN declined DROP DEFAULT,
+	ALTER COLUMN declined TYPE INT4;
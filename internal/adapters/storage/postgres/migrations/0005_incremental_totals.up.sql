@@ -0,0 +1,19 @@
+-- totals are maintained by writers as tasks enter and leave final states, so the table
+-- keeps a single row. Rows added by previous versions on every read are replaced by
+-- counters calculated from the current state of tasks
+LOCK TABLE analytics.totals IN EXCLUSIVE MODE;
+DELETE FROM analytics.totals;
+ALTER TABLE analytics.totals
+	ALTER COLUMN id SET NOT NULL,
+	ALTER COLUMN finished TYPE INT8,
+	ALTER COLUMN finished SET DEFAULT 0,
+	ALTER COLUMN finished SET NOT NULL,
+	ALTER COLUMN declined TYPE INT8,
+	ALTER COLUMN declined SET DEFAULT 0,
+	ALTER COLUMN declined SET NOT NULL,
+	ADD CONSTRAINT totals_pkey PRIMARY KEY (id);
+INSERT INTO analytics.totals (id, finished, declined)
+SELECT 0,
+	count(*) FILTER (WHERE e.event_type = 'FINISHED'),
+	count(*) FILTER (WHERE e.event_type IN ('DECLINED', 'DELETED'))
+FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id;
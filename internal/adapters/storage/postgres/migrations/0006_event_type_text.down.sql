@@ -0,0 +1,15 @@
+-- fails if events outside of the default lifecycle have been stored
+DO $$ BEGIN
+	CREATE TYPE event_t AS enum
+	(
+		'CREATED',
+		'MESSAGE_SENT',
+		'APPROVED',
+		'DECLINED',
+		'FINISHED',
+		'DELETED'
+	);
+EXCEPTION
+	WHEN duplicate_object THEN NULL;
+END $$;
+ALTER TABLE analytics.events ALTER COLUMN event_type TYPE event_t USING event_type::event_t;
@@ -0,0 +1,3 @@
+-- event types are defined by the transition table, so the column accepts any of them
+ALTER TABLE analytics.events ALTER COLUMN event_type TYPE varchar(64) USING event_type::text;
+DROP TYPE IF EXISTS event_t;
@@ -4,58 +4,31 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
 )
 
-const (
-	DDL = `
-
-	DROP SCHEMA IF EXISTS analytics CASCADE;
-	DROP TYPE IF EXISTS event_t CASCADE;
-
-	CREATE SCHEMA IF NOT EXISTS analytics;
-	CREATE TYPE event_t AS enum
-	(
-		'CREATED',
-		'MESSAGE_SENT',
-		'APPROVED',
-		'DECLINED',
-		'FINISHED',
-		'DELETED'
-	);	
-	CREATE TABLE IF NOT EXISTS analytics.events
-	(
-		id serial4 NOT NULL,
-		task_id INT4 NOT NULL,
-		event_type event_t NOT NULL,
-		approver_email varchar(256) NOT NULL,
-		recieved_at timestamp with time zone NOT NULL,
-		total_delay interval SECOND DEFAULT NULL,
-	
-		CONSTRAINT events_pkey PRIMARY KEY (id)
-	);
-	CREATE TABLE IF NOT EXISTS analytics.totals
-	(
-		id INT DEFAULT 0,
-		finished INT4,
-		declined INT4
-	);
-	`
-)
+// uniqueViolation is the postgres error code of a unique constraint violation
+const uniqueViolation = "23505"
 
 // Store ...
 type Store struct {
 	Pool *pgxpool.Pool
+
+	// tx is set on a store passed to Atomic callbacks
+	tx pgx.Tx
 }
 
-// Init creates schema, type and tables
+// Init brings schema, types and tables up to date applying pending migrations,
+// data stored by previous versions is kept
 func (s *Store) Init(ctx context.Context) error {
-	_, err := s.Pool.Exec(ctx, DDL)
-	return err
+	return s.MigrateUp(ctx)
 }
 
 // New ...
@@ -75,100 +48,199 @@ func New(dsn string) (s *Store, err error) {
 	return s, nil
 }
 
-// Insert adds event about task that has not been stored yet
+// Insert adds event about task that has not been stored yet. The task is created by the same
+// statement, so a concurrent insert of the same task fails on the primary key
 func (s *Store) Insert(ctx context.Context, msg *models.Message) error {
-	query := "INSERT INTO analytics.events (task_id, event_type, approver_email, recieved_at, total_delay) values ($1, $2, $3, $4, interval '0 second') RETURNING id"
-	row := s.Pool.QueryRow(ctx, query,
+	query := `WITH evt AS (
+		INSERT INTO analytics.events (task_id, event_type, approver_email, recieved_at, delay, total_delay, message_id)
+		VALUES ($1, $2, $3, $4, interval '0 second', interval '0 second', NULLIF($5, ''))
+		RETURNING id, task_id
+	)
+	INSERT INTO analytics.tasks (task_id, event_id) SELECT task_id, id FROM evt`
+	_, err := s.db().Exec(ctx, query,
 		msg.TaskID,
 		msg.EventType,
 		msg.Approver,
 		msg.RecievedAt,
+		msg.ID,
 	)
-
-	var id uint64
-	if err := row.Scan(&id); err != nil {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == "tasks_pkey" {
+		return fmt.Errorf("%w: error inserting new event in db: %v", ports.ErrTaskExists, err)
+	}
+	if err != nil {
 		return fmt.Errorf("error inserting new event in db: %v", err)
 	}
 
 	return nil
 }
 
-// Select extracts an event with specified ID
-func (s *Store) Select(ctx context.Context, taskID uint64) (*models.Message, error) {
+// Select extracts the current state of a task with specified ID, that is the last event of the task.
+// Inside Atomic the task row is locked till the end of the transaction
+func (s *Store) Select(ctx context.Context, taskID uint64) (*models.Event, error) {
 	evt := &models.Event{}
-	query := "SELECT event_type, task_id, approver_email, recieved_at FROM analytics.events WHERE task_id=$1"
-	err := s.Pool.QueryRow(ctx, query, taskID).Scan(&evt.EventType, &evt.TaskID, &evt.Approver, &evt.RecievedAt)
-
-	// ErrNoRows is a handled situation meaning a massage with a new task is received
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return nil, err
+	query := `SELECT e.id, e.event_type, e.task_id, e.approver_email, e.recieved_at, e.delay, e.total_delay
+	FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id
+	WHERE t.task_id=$1`
+	if s.tx != nil {
+		query += ` FOR UPDATE OF t`
 	}
+	err := s.db().QueryRow(ctx, query, taskID).Scan(&evt.ID, &evt.EventType, &evt.TaskID, &evt.Approver, &evt.RecievedAt, &evt.Delay, &evt.TotalDelay)
 
+	// ErrNoRows is a handled situation meaning a massage with a new task is received
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return &models.Message{
-		EventType:  evt.EventType,
-		TaskID:     evt.TaskID,
-		Approver:   evt.Approver,
-		RecievedAt: evt.RecievedAt,
-	}, nil
+	return evt, nil
 }
 
-// Update changes event about particular task in database with msg values
+// Update appends an event about particular task with msg values, the accumulated delay is kept
 func (s *Store) Update(ctx context.Context, msg *models.Message) error {
-	query := "UPDATE analytics.events SET event_type=$1, approver_email=$2, recieved_at=$3 WHERE task_id=$4"
-	_, err := s.Pool.Exec(ctx, query, msg.EventType, msg.Approver, msg.RecievedAt, msg.TaskID)
-	return err
+	return s.appendEvent(ctx, msg, false)
 }
 
-// UpdateDelay sets data about particular task in database with msg values and calculated delay
+// UpdateDelay appends an event about particular task with msg values and the delay
+// calculated since the previous event
 func (s *Store) UpdateDelay(ctx context.Context, msg *models.Message) error {
-	var (
-		timeStamp time.Time
-		delay     time.Duration
+	return s.appendEvent(ctx, msg, true)
+}
+
+// appendEvent adds a new event to the task log and moves the task to it, with withDelay
+// the time passed since the previous event is added to the accumulated delay.
+// Both tables are changed by a single statement, so the task never points to a missing event
+// and the delay is calculated from the event the task points to at the moment
+func (s *Store) appendEvent(ctx context.Context, msg *models.Message, withDelay bool) error {
+	query := `WITH prev AS (
+		SELECT e.recieved_at, e.total_delay FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id WHERE t.task_id=$1
+	), lag AS (
+		SELECT CASE WHEN $5::boolean THEN COALESCE($4::timestamptz - (SELECT recieved_at FROM prev), interval '0 second')
+			ELSE interval '0 second' END AS delay
+	), evt AS (
+		INSERT INTO analytics.events (task_id, event_type, approver_email, recieved_at, delay, total_delay, message_id)
+		VALUES ($1, $2, $3, $4, (SELECT delay FROM lag),
+			COALESCE((SELECT total_delay FROM prev), interval '0 second') + (SELECT delay FROM lag), NULLIF($6, ''))
+		RETURNING id, task_id
+	)
+	INSERT INTO analytics.tasks (task_id, event_id) SELECT task_id, id FROM evt
+	ON CONFLICT (task_id) DO UPDATE SET event_id = EXCLUDED.event_id`
+	_, err := s.db().Exec(ctx, query,
+		msg.TaskID,
+		msg.EventType,
+		msg.Approver,
+		msg.RecievedAt,
+		withDelay,
+		msg.ID,
 	)
-	query := "SELECT recieved_at, total_delay FROM analytics.events WHERE task_id=$1"
-	err := s.Pool.QueryRow(ctx, query, msg.TaskID).Scan(&timeStamp, &delay)
+	return err
+}
+
+// AddTotals changes counters of tasks in a final state. Inside Atomic the counters stay
+// locked till the end of the transaction, so concurrent writers update them one by one
+func (s *Store) AddTotals(ctx context.Context, finished, declined int64) error {
+	query := `INSERT INTO analytics.totals (id, finished, declined) VALUES (0, $1, $2)
+	ON CONFLICT (id) DO UPDATE SET finished = totals.finished + EXCLUDED.finished,
+		declined = totals.declined + EXCLUDED.declined`
+	if _, err := s.db().Exec(ctx, query, finished, declined); err != nil {
+		return fmt.Errorf("error updating totals: %v", err)
+	}
+	return nil
+}
+
+// RecountTotals replaces counters by numbers of tasks in finished and in declined states.
+// The counters are locked first, so writers changing them meanwhile add their changes to the new values
+func (s *Store) RecountTotals(ctx context.Context, finished, declined []string) error {
+	tx, err := s.Pool.Begin(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("error starting transaction: %v", err)
 	}
+	defer tx.Rollback(context.Background())
 
-	duration := msg.RecievedAt.Sub(timeStamp) + delay
-	query = "UPDATE analytics.events SET event_type=$1, approver_email=$2, recieved_at=$3, total_delay=$4 WHERE task_id=$5"
-	_, err = s.Pool.Exec(ctx, query, msg.EventType, msg.Approver, msg.RecievedAt, duration, msg.TaskID)
-	return err
+	if _, err = tx.Exec(ctx, `LOCK TABLE analytics.totals IN EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("error locking totals: %v", err)
+	}
+	query := `INSERT INTO analytics.totals (id, finished, declined)
+	SELECT 0,
+		count(*) FILTER (WHERE e.event_type = ANY($1)),
+		count(*) FILTER (WHERE e.event_type = ANY($2))
+	FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id
+	ON CONFLICT (id) DO UPDATE SET finished = EXCLUDED.finished, declined = EXCLUDED.declined`
+	if _, err = tx.Exec(ctx, query, finished, declined); err != nil {
+		return fmt.Errorf("error recounting totals: %v", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
 }
 
-// GetAggregates extracts statistics about finished and declined tasks and its delay
-func (s *Store) GetAggregates(ctx context.Context) (*models.Totals, []models.Delay, error) {
+// Seen reports whether an event with the message id has already been stored
+func (s *Store) Seen(ctx context.Context, messageID string) (bool, error) {
+	var seen bool
+	query := `SELECT EXISTS (SELECT 1 FROM analytics.events WHERE message_id=$1)`
+	if err := s.db().QueryRow(ctx, query, messageID).Scan(&seen); err != nil {
+		return false, fmt.Errorf("error checking message id %s: %v", messageID, err)
+	}
+
+	return seen, nil
+}
 
-	// refresh totals in DB
-	err := s.calculateAggregates(ctx)
+// History extracts all events of the task in the order they have been stored
+func (s *Store) History(ctx context.Context, taskID uint64) ([]models.Event, error) {
+	query := `SELECT id, event_type, task_id, approver_email, recieved_at, delay, total_delay
+	FROM analytics.events WHERE task_id=$1 ORDER BY id`
+	rows, err := s.db().Query(ctx, query, taskID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error calculating aggregates, %v", err)
+		return nil, fmt.Errorf("error selecting task history: %v", err)
 	}
+	defer rows.Close()
 
-	var (
-		totals models.Totals
-		delay  models.Delay
-	)
-	// get totals
+	events := make([]models.Event, 0)
+	for rows.Next() {
+		var evt models.Event
+		err = rows.Scan(&evt.ID, &evt.EventType, &evt.TaskID, &evt.Approver, &evt.RecievedAt, &evt.Delay, &evt.TotalDelay)
+		if err != nil {
+			return nil, fmt.Errorf("error reading task history: %v", err)
+		}
+		events = append(events, evt)
+	}
+
+	return events, rows.Err()
+}
+
+// GetTotals reads numbers of finished and declined tasks maintained by writers
+func (s *Store) GetTotals(ctx context.Context) (*models.Totals, error) {
+	var totals models.Totals
 	query := `SELECT finished, declined FROM analytics.totals as t WHERE t.id=0;`
 	if err := s.Pool.QueryRow(ctx, query).Scan(&totals.Finished, &totals.Declined); err != nil {
-		return nil, nil, fmt.Errorf("error reading totals (finished and declined tasks): %v", err)
+		return nil, fmt.Errorf("error reading totals (finished and declined tasks): %v", err)
+	}
+
+	return &totals, nil
+}
+
+// GetAggregates extracts statistics about finished and declined tasks and its delay.
+// Delays of tasks in filter.Final states are filtered and paginated by the filter, nil filter selects no delays
+func (s *Store) GetAggregates(ctx context.Context, filter *models.DelayFilter) (*models.Totals, []models.Delay, error) {
+	var delay models.Delay
+	totals, err := s.GetTotals(ctx)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// get delays
-	query = `SELECT task_id, total_delay t_delay FROM analytics.events e WHERE e.total_delay IS NOT NULL AND e.event_type in ('DECLINED', 'FINISHED', 'DELETED');`
-	rows, err := s.Pool.Query(ctx, query)
+	query, args := delaysQuery(filter)
+	rows, err := s.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error selecting task delays: %v", err)
 	}
 	defer rows.Close()
 
-	delays := make([]models.Delay, 0, totals.Finished+totals.Declined)
+	delays := make([]models.Delay, 0)
 	for rows.Next() {
 		err = rows.Scan(&delay.ID, &delay.Lag)
 		if err != nil {
@@ -177,45 +249,278 @@ func (s *Store) GetAggregates(ctx context.Context) (*models.Totals, []models.Del
 		delays = append(delays, delay)
 	}
 
-	// TODO: change to separate table and simplify this method to jush read values
-	return &totals, delays, nil
+	return totals, delays, rows.Err()
 }
 
-// calculateAggregates updates data in analytics.totals counting FINISHED and DECLINED
-// tasks. Also counts number of not nil delays to pass to caller function.
-// Queries are executed in a transaction
-func (s *Store) calculateAggregates(ctx context.Context) error {
+// delaysQuery composes a query selecting delays of tasks in a final state with the filter applied
+func delaysQuery(filter *models.DelayFilter) (string, []interface{}) {
+	if filter == nil {
+		filter = &models.DelayFilter{}
+	}
+
+	cond, args := finalStateCondition(filter.Final, "", filter.From, filter.To, make([]interface{}, 0, 5))
+	query := `SELECT t.task_id, e.total_delay t_delay
+	FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id
+	WHERE ` + cond
 
-	tx, err := s.Pool.Begin(ctx)
+	order, cmp := "ASC", ">"
+	if filter.Desc {
+		order, cmp = "DESC", "<"
+	}
+	if filter.Cursor != 0 {
+		args = append(args, filter.Cursor)
+		query += fmt.Sprintf(" AND t.task_id %s $%d", cmp, len(args))
+	}
+	query += " ORDER BY t.task_id " + order
+	if filter.Limit != 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return query + ";", args
+}
+
+// finalStateCondition composes a condition on the last event e of tasks in one of final states.
+// eventType narrows down the final state, from and to bound the time the state is reached.
+// Values are appended to args as query parameters
+func finalStateCondition(final []string, eventType string, from, to time.Time, args []interface{}) (string, []interface{}) {
+	args = append(args, final)
+	cond := fmt.Sprintf("e.event_type = ANY($%d)", len(args))
+	if eventType != "" {
+		args = append(args, eventType)
+		cond += fmt.Sprintf(" AND e.event_type = $%d", len(args))
+	}
+	if !from.IsZero() {
+		args = append(args, from)
+		cond += fmt.Sprintf(" AND e.recieved_at >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		cond += fmt.Sprintf(" AND e.recieved_at < $%d", len(args))
+	}
+
+	return cond, args
+}
+
+// DelayStats calculates statistics and a histogram on delays of tasks in a final state
+func (s *Store) DelayStats(ctx context.Context, filter *models.StatsFilter) (*models.DelayStats, error) {
+	if filter == nil {
+		filter = &models.StatsFilter{}
+	}
+
+	cond, args := finalStateCondition(filter.Final, filter.EventType, filter.From, filter.To, make([]interface{}, 0, 7))
+	delays := `SELECT extract(epoch FROM e.total_delay)::float8 lag
+	FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id
+	WHERE ` + cond
+
+	var (
+		stats                                 models.DelayStats
+		min, max, mean, median, p90, p95, p99 float64
+	)
+	query := `SELECT count(*), COALESCE(min(lag), 0), COALESCE(max(lag), 0), COALESCE(avg(lag), 0),
+		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY lag), 0),
+		COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY lag), 0),
+		COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY lag), 0),
+		COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY lag), 0)
+	FROM (` + delays + `) d;`
+	err := s.Pool.QueryRow(ctx, query, args...).Scan(&stats.Count, &min, &max, &mean, &median, &p90, &p95, &p99)
 	if err != nil {
-		return fmt.Errorf("error on transaction begin, %v", err)
+		return nil, fmt.Errorf("error calculating delay statistics: %v", err)
 	}
-	// Rollback is safe to call even if the tx is already closed, so if
-	// the tx commits successfully, this is a no-op
-	defer tx.Rollback(context.Background())
+	stats.Min, stats.Max, stats.Mean = seconds(min), seconds(max), seconds(mean)
+	stats.Median, stats.P90, stats.P95, stats.P99 = seconds(median), seconds(p90), seconds(p95), seconds(p99)
+
+	stats.Histogram = make([]models.Bucket, 0, filter.Buckets)
+	if stats.Count == 0 || filter.Buckets == 0 {
+		return &stats, nil
+	}
+	// all delays are equal, there is nothing to split
+	if min == max {
+		stats.Histogram = append(stats.Histogram, models.Bucket{From: stats.Min, To: stats.Max, Count: stats.Count})
+		return &stats, nil
+	}
+
+	// width_bucket puts max into an extra bucket, so it is moved to the last one
+	n := len(args)
+	query = fmt.Sprintf(`SELECT LEAST(width_bucket(lag, $%d, $%d, $%d), $%d) b, count(*)
+	FROM (%s) d GROUP BY b ORDER BY b;`, n+1, n+2, n+3, n+3, delays)
+	args = append(args, min, max, filter.Buckets)
+	rows, err := s.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating delay histogram: %v", err)
+	}
+	defer rows.Close()
+
+	width := (max - min) / float64(filter.Buckets)
+	for i := uint64(0); i < filter.Buckets; i++ {
+		stats.Histogram = append(stats.Histogram, models.Bucket{
+			From: seconds(min + width*float64(i)),
+			To:   seconds(min + width*float64(i+1)),
+		})
+	}
+	stats.Histogram[filter.Buckets-1].To = stats.Max
+
+	for rows.Next() {
+		var (
+			bucket uint64
+			count  uint64
+		)
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, fmt.Errorf("error reading delay histogram: %v", err)
+		}
+		// buckets are numbered from 1
+		if bucket < 1 || bucket > filter.Buckets {
+			continue
+		}
+		stats.Histogram[bucket-1].Count = count
+	}
+
+	return &stats, rows.Err()
+}
 
-	query := `INSERT INTO analytics.totals (id, finished, declined) VALUES (0,0,0);`
-	_, err = tx.Exec(ctx, query)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return fmt.Errorf("error inserting 0-values in totals, %v", err)
+// TimeSeries groups tasks in a final state into buckets truncated by date_trunc in the filter
+// location, buckets without tasks are omitted
+func (s *Store) TimeSeries(ctx context.Context, filter *models.SeriesFilter) ([]models.Point, error) {
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
 	}
 
-	query = `UPDATE analytics.totals t SET (finished, declined) = (
-		(select  Sum(case when event_type = 'FINISHED' then 1 else 0 end) from analytics.events),
-		(select  Sum(case when event_type in ('DECLINED', 'DELETED') then 1 else 0 end) from analytics.events)
+	var (
+		cond  string
+		args  = make([]interface{}, 0, 6)
+		value string
 	)
-	WHERE t.id=0;`
-	_, err = tx.Exec(ctx, query)
+	switch filter.Metric {
+	case models.MetricFinished:
+		cond, args = finalStateCondition(filter.Final, models.Finished, filter.From, filter.To, args)
+		value = "count(*)::float8"
+	case models.MetricDeclined:
+		cond, args = finalStateCondition(filter.Final, "", filter.From, filter.To, args)
+		args = append(args, models.Finished)
+		cond += fmt.Sprintf(" AND e.event_type <> $%d", len(args))
+		value = "count(*)::float8"
+	case models.MetricAvgLag:
+		cond, args = finalStateCondition(filter.Final, "", filter.From, filter.To, args)
+		value = "avg(extract(epoch FROM e.total_delay))::float8"
+	default:
+		return nil, fmt.Errorf("unknown time series metric %s", filter.Metric)
+	}
+
+	// timestamps are truncated as local time of the location and converted back to absolute time
+	args = append(args, filter.Interval, loc.String())
+	bucket := fmt.Sprintf("date_trunc($%d, e.recieved_at AT TIME ZONE $%d) AT TIME ZONE $%d", len(args)-1, len(args), len(args))
+	query := `SELECT ` + bucket + ` b, ` + value + `
+	FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id
+	WHERE ` + cond + `
+	GROUP BY b ORDER BY b;`
+	rows, err := s.Pool.Query(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("error updating totals, %v", err)
+		return nil, fmt.Errorf("error selecting time series: %v", err)
+	}
+	defer rows.Close()
+
+	points := make([]models.Point, 0)
+	for rows.Next() {
+		var (
+			p models.Point
+			v float64
+		)
+		if err = rows.Scan(&p.Start, &v); err != nil {
+			return nil, fmt.Errorf("error reading time series: %v", err)
+		}
+		p.Start = p.Start.In(loc)
+		if filter.Metric == models.MetricAvgLag {
+			p.Value = int64(seconds(v))
+		} else {
+			p.Value = int64(v)
+		}
+		points = append(points, p)
 	}
 
-	err = tx.Commit(ctx)
+	return points, rows.Err()
+}
+
+// ApproverStats extracts response statistics grouped by approver,
+// empty email means all approvers
+func (s *Store) ApproverStats(ctx context.Context, email string, states models.ResponseStates) ([]models.ApproverStats, error) {
+	query := `WITH lags AS (
+		SELECT approver_email,
+			count(*) FILTER (WHERE event_type = ANY($2)) approved,
+			count(*) FILTER (WHERE event_type = ANY($3)) declined,
+			avg(extract(epoch FROM delay))::float8 avg_lag,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY extract(epoch FROM delay)) median_lag,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY extract(epoch FROM delay)) p95_lag
+		FROM analytics.events
+		WHERE (event_type = ANY($2) OR event_type = ANY($3)) AND ($1::text = '' OR approver_email = $1::text)
+		GROUP BY approver_email
+	), pending AS (
+		SELECT e.approver_email, count(*) pending
+		FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id
+		WHERE e.event_type = ANY($4) AND ($1::text = '' OR e.approver_email = $1::text)
+		GROUP BY e.approver_email
+	)
+	SELECT COALESCE(l.approver_email, p.approver_email),
+		COALESCE(l.approved, 0), COALESCE(l.declined, 0), COALESCE(p.pending, 0),
+		COALESCE(l.avg_lag, 0), COALESCE(l.median_lag, 0), COALESCE(l.p95_lag, 0)
+	FROM lags l FULL JOIN pending p ON p.approver_email = l.approver_email
+	ORDER BY 1;`
+	rows, err := s.Pool.Query(ctx, query, email, states.Approved, states.Declined, states.Awaiting)
 	if err != nil {
-		return fmt.Errorf("error on transaction commit, %v", err)
+		return nil, fmt.Errorf("error selecting approver statistics: %v", err)
 	}
+	defer rows.Close()
 
-	return nil
+	stats := make([]models.ApproverStats, 0)
+	for rows.Next() {
+		var (
+			st                  models.ApproverStats
+			avg, median, p95Lag float64
+		)
+		err = rows.Scan(&st.Email, &st.Approved, &st.Declined, &st.Pending, &avg, &median, &p95Lag)
+		if err != nil {
+			return nil, fmt.Errorf("error reading approver statistics: %v", err)
+		}
+		st.AvgLag, st.MedianLag, st.P95Lag = seconds(avg), seconds(median), seconds(p95Lag)
+		stats = append(stats, st)
+	}
+
+	return stats, rows.Err()
+}
+
+// PendingStats counts tasks in every one of states, states without tasks are omitted
+func (s *Store) PendingStats(ctx context.Context, now time.Time, states []string) ([]models.PendingState, error) {
+	query := `SELECT e.event_type, count(*),
+		avg(extract(epoch FROM $1::timestamptz - e.recieved_at))::float8,
+		max(extract(epoch FROM $1::timestamptz - e.recieved_at))::float8
+	FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id
+	WHERE e.event_type = ANY($2)
+	GROUP BY e.event_type`
+	rows, err := s.Pool.Query(ctx, query, now, states)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting pending tasks: %v", err)
+	}
+	defer rows.Close()
+
+	stats := make([]models.PendingState, 0)
+	for rows.Next() {
+		var (
+			st          models.PendingState
+			avg, maxAge float64
+		)
+		if err = rows.Scan(&st.State, &st.Count, &avg, &maxAge); err != nil {
+			return nil, fmt.Errorf("error reading pending tasks: %v", err)
+		}
+		st.AvgAge, st.MaxAge = seconds(avg), seconds(maxAge)
+		stats = append(stats, st)
+	}
+
+	return stats, rows.Err()
+}
+
+// seconds converts seconds returned by extract(epoch ...) to time.Duration
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
 }
 
 // Drop clears database (for testing purpose onle)
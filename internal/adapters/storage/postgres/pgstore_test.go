@@ -5,12 +5,15 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"os"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/seggga/approve-analytics/internal/adapters/storage/storagetest"
 	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
 )
 
 const (
@@ -20,6 +23,15 @@ const (
 var (
 	store *Store
 
+	// final, pending and response states of the default transition table
+	finalStates    = []string{models.Declined, models.Finished, models.Deleted}
+	pendingStates  = []string{models.Created, models.MessageSent, models.Approved}
+	responseStates = models.ResponseStates{
+		Approved: []string{models.Approved},
+		Declined: []string{models.Declined},
+		Awaiting: []string{models.MessageSent},
+	}
+
 	taskInsert = uint64(12)
 	timeStamp  = time.Now()
 
@@ -98,7 +110,13 @@ var (
 )
 
 func TestMain(m *testing.M) {
-	store, _ = New(DSN)
+	var err error
+	store, err = New(DSN)
+	if err != nil {
+		os.Exit(1)
+	}
+	// migrations keep stored data, so the schema is dropped to start from scratch
+	_ = store.Drop(context.TODO())
 	_ = store.Init(context.TODO())
 
 	defer clearDB()
@@ -163,6 +181,30 @@ func TestUpdateDelay(t *testing.T) {
 	}
 
 }
+
+// test that every event of the approval sequence is kept in the log
+func TestHistory(t *testing.T) {
+	events, err := store.History(context.TODO(), taskInsert)
+	if err != nil {
+		t.Fatalf("unexpected error on history: %v", err)
+	}
+
+	expected := []models.Message{msgInsert, msgUpdate, msgUpdateDelay}
+	if len(events) != len(expected) {
+		t.Fatalf("wrong history len: expected %d, got %d", len(expected), len(events))
+	}
+	for i, evt := range events {
+		if evt.EventType != expected[i].EventType || evt.Approver != expected[i].Approver {
+			t.Fatalf("wrong event %d: expected %v, got %v", i, expected[i], evt)
+		}
+	}
+
+	last := events[len(events)-1]
+	if last.Delay != 20*time.Second || last.TotalDelay != 20*time.Second {
+		t.Fatalf("wrong delay of the last event: expected %v, got %v, %v", 20*time.Second, last.Delay, last.TotalDelay)
+	}
+}
+
 func TestGetAggregates(t *testing.T) {
 	// store, _ = New(DSN)
 
@@ -201,8 +243,12 @@ func TestGetAggregates(t *testing.T) {
 			t.Fatalf("error updating messages with delay , %v", err)
 		}
 	}
+	// totals are maintained by the analytics service, the store only applies deltas
+	if err := store.AddTotals(ctx, 2, 2); err != nil {
+		t.Fatalf("error updating totals, %v", err)
+	}
 
-	totals, delays, err := store.GetAggregates(ctx)
+	totals, delays, err := store.GetAggregates(ctx, &models.DelayFilter{Final: finalStates})
 	if err != nil {
 		t.Fatalf("error getting statistics, %v", err)
 	}
@@ -224,6 +270,384 @@ func TestGetAggregates(t *testing.T) {
 	}
 }
 
+// depends on tasks 101-104 stored by TestGetAggregates
+func TestGetAggregatesFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   models.DelayFilter
+		expected []uint64
+	}{
+		{
+			name:     "first page, descending",
+			filter:   models.DelayFilter{Final: finalStates, Limit: 2, Desc: true},
+			expected: []uint64{104, 103},
+		},
+		{
+			name:     "second page, descending",
+			filter:   models.DelayFilter{Final: finalStates, Cursor: 103, Limit: 2, Desc: true},
+			expected: []uint64{102, 101},
+		},
+		{
+			name:     "time range",
+			filter:   models.DelayFilter{Final: finalStates, From: timeStamp.Add(-75 * time.Second), To: timeStamp.Add(-55 * time.Second)},
+			expected: []uint64{102, 103},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, delays, err := store.GetAggregates(context.TODO(), &tt.filter)
+			if err != nil {
+				t.Fatalf("error getting statistics, %v", err)
+			}
+
+			got := make([]uint64, 0, len(delays))
+			for _, d := range delays {
+				got = append(got, d.ID)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("got wrong task ids: expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// depends on tasks 101-104 stored by TestGetAggregates, delays are 20, 30, 40 and 50 seconds
+func TestDelayStats(t *testing.T) {
+	stats, err := store.DelayStats(context.TODO(), &models.StatsFilter{Final: finalStates, Buckets: 3})
+	if err != nil {
+		t.Fatalf("error getting delay statistics, %v", err)
+	}
+
+	if stats.Count != 4 || stats.Min != 20*time.Second || stats.Max != 50*time.Second ||
+		stats.Mean != 35*time.Second || stats.Median != 35*time.Second {
+		t.Fatalf("wrong delay statistics: %v", stats)
+	}
+
+	expected := []models.Bucket{
+		{From: 20 * time.Second, To: 30 * time.Second, Count: 1},
+		{From: 30 * time.Second, To: 40 * time.Second, Count: 1},
+		{From: 40 * time.Second, To: 50 * time.Second, Count: 2},
+	}
+	if !reflect.DeepEqual(stats.Histogram, expected) {
+		t.Fatalf("wrong histogram: expected %v, got %v", expected, stats.Histogram)
+	}
+
+	stats, err = store.DelayStats(context.TODO(), &models.StatsFilter{Final: finalStates, EventType: models.Declined, Buckets: 3})
+	if err != nil {
+		t.Fatalf("error getting delay statistics, %v", err)
+	}
+	if stats.Count != 2 || stats.Min != 30*time.Second || stats.Max != 50*time.Second {
+		t.Fatalf("wrong statistics on declined tasks: %v", stats)
+	}
+}
+
+// depends on events stored by the previous tests
+func TestApproverStats(t *testing.T) {
+	expected := map[string]models.ApproverStats{
+		"approver@mail.com": {
+			Email:     "approver@mail.com",
+			Approved:  1,
+			AvgLag:    20 * time.Second,
+			MedianLag: 20 * time.Second,
+			P95Lag:    20 * time.Second,
+		},
+		"approver102@mail.com": {
+			Email:     "approver102@mail.com",
+			Declined:  1,
+			AvgLag:    30 * time.Second,
+			MedianLag: 30 * time.Second,
+			P95Lag:    30 * time.Second,
+		},
+	}
+
+	for email, exp := range expected {
+		stats, err := store.ApproverStats(context.TODO(), email, responseStates)
+		if err != nil {
+			t.Fatalf("error getting approver statistics, %v", err)
+		}
+		if len(stats) != 1 || stats[0] != exp {
+			t.Fatalf("wrong statistics of %s: expected %v, got %v", email, exp, stats)
+		}
+	}
+}
+
+func TestSeen(t *testing.T) {
+	ctx := context.TODO()
+	msg := models.Message{
+		ID:         "msg-105",
+		EventType:  models.Created,
+		TaskID:     105,
+		RecievedAt: timeStamp.Add(-10 * time.Second),
+	}
+
+	if err := store.Insert(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on insert: %v", err)
+	}
+
+	seen, err := store.Seen(ctx, msg.ID)
+	if err != nil || !seen {
+		t.Fatalf("stored message id has not been seen: %v, %v", seen, err)
+	}
+	seen, err = store.Seen(ctx, "msg-unknown")
+	if err != nil || seen {
+		t.Fatalf("unknown message id has been seen: %v, %v", seen, err)
+	}
+
+	if err := store.Update(ctx, &msg); err == nil {
+		t.Fatalf("message with the same id has been stored twice")
+	}
+}
+
+func TestAtomic(t *testing.T) {
+	ctx := context.TODO()
+	msg := models.Message{
+		EventType:  models.Created,
+		TaskID:     106,
+		RecievedAt: timeStamp.Add(-10 * time.Second),
+	}
+
+	errRollback := errors.New("rollback")
+	err := store.Atomic(ctx, func(tx ports.EventStorage) error {
+		if err := tx.Insert(ctx, &msg); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected error of the callback, got %v", err)
+	}
+
+	gotMsg, err := store.Select(ctx, msg.TaskID)
+	if err != nil || gotMsg != nil {
+		t.Fatalf("rolled back message has been stored: %v, %v", gotMsg, err)
+	}
+
+	err = store.Atomic(ctx, func(tx ports.EventStorage) error {
+		return tx.Insert(ctx, &msg)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error on insert: %v", err)
+	}
+	if err := store.Insert(ctx, &msg); err == nil {
+		t.Fatalf("task has been inserted twice")
+	}
+}
+
+func TestSLABreaches(t *testing.T) {
+	ctx := context.TODO()
+	sentAt := timeStamp.Add(-2 * time.Hour).Truncate(time.Microsecond)
+	for _, msg := range []models.Message{
+		{EventType: models.Created, TaskID: 107, RecievedAt: sentAt.Add(-time.Minute)},
+		{EventType: models.MessageSent, TaskID: 107, Approver: "approver107@mail.com", RecievedAt: sentAt},
+	} {
+		msg := msg
+		err := store.Atomic(ctx, func(tx ports.EventStorage) error {
+			evt, err := tx.Select(ctx, msg.TaskID)
+			if err != nil {
+				return err
+			}
+			if evt == nil {
+				return tx.Insert(ctx, &msg)
+			}
+			return tx.Update(ctx, &msg)
+		})
+		if err != nil {
+			t.Fatalf("unexpected error on storing message: %v", err)
+		}
+	}
+
+	pending, err := store.PendingApprovals(ctx, timeStamp.Add(-time.Hour), []string{models.MessageSent})
+	if err != nil {
+		t.Fatalf("unexpected error on getting pending approvals: %v", err)
+	}
+	var found bool
+	for _, evt := range pending {
+		if evt.TaskID == 107 {
+			found = evt.Approver == "approver107@mail.com" && evt.RecievedAt.Equal(sentAt)
+		}
+	}
+	if !found {
+		t.Fatalf("task 107 has not been found among pending approvals: %v", pending)
+	}
+
+	breach := models.Breach{
+		TaskID:     107,
+		Approver:   "approver107@mail.com",
+		SentAt:     sentAt,
+		Waited:     2 * time.Hour,
+		Limit:      time.Hour,
+		DetectedAt: timeStamp,
+	}
+	recorded, err := store.RecordBreaches(ctx, []models.Breach{breach})
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("breach has not been recorded: %v, %v", recorded, err)
+	}
+	recorded, err = store.RecordBreaches(ctx, []models.Breach{breach})
+	if err != nil || len(recorded) != 0 {
+		t.Fatalf("breach has been recorded twice: %v, %v", recorded, err)
+	}
+}
+
+func TestPendingStats(t *testing.T) {
+	ctx := context.TODO()
+
+	before, err := store.PendingStats(ctx, timeStamp, pendingStates)
+	if err != nil {
+		t.Fatalf("unexpected error on getting pending tasks: %v", err)
+	}
+
+	msg := models.Message{EventType: models.Created, TaskID: 108, RecievedAt: timeStamp.Add(-time.Hour)}
+	if err := store.Insert(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on insert: %v", err)
+	}
+
+	after, err := store.PendingStats(ctx, timeStamp, pendingStates)
+	if err != nil {
+		t.Fatalf("unexpected error on getting pending tasks: %v", err)
+	}
+	count := func(stats []models.PendingState) (uint64, time.Duration) {
+		for _, st := range stats {
+			if st.State == models.Created {
+				return st.Count, st.MaxAge
+			}
+		}
+		return 0, 0
+	}
+	countBefore, _ := count(before)
+	countAfter, maxAge := count(after)
+	if countAfter != countBefore+1 || maxAge < time.Hour {
+		t.Fatalf("created task has not been counted: before %v, after %v", before, after)
+	}
+}
+
+func TestTimeSeries(t *testing.T) {
+	ctx := context.TODO()
+	day := time.Date(2021, 3, 10, 12, 0, 0, 0, time.UTC)
+	for i, msg := range []models.Message{
+		{EventType: models.Created, TaskID: 109, RecievedAt: day.Add(-2 * time.Hour)},
+		{EventType: models.Finished, TaskID: 109, RecievedAt: day},
+		{EventType: models.Created, TaskID: 110, RecievedAt: day.Add(-2 * time.Hour)},
+		{EventType: models.Finished, TaskID: 110, RecievedAt: day.Add(11 * time.Hour)},
+	} {
+		var err error
+		if i%2 == 0 {
+			err = store.Insert(ctx, &msg)
+		} else {
+			err = store.UpdateDelay(ctx, &msg)
+		}
+		if err != nil {
+			t.Fatalf("unexpected error on storing message: %v", err)
+		}
+	}
+
+	from, to := day.AddDate(0, 0, -3), day.AddDate(0, 0, 3)
+	points, err := store.TimeSeries(ctx, &models.SeriesFilter{
+		Final: finalStates, Interval: models.IntervalDay, Metric: models.MetricFinished, Location: time.UTC, From: from, To: to,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error on getting time series: %v", err)
+	}
+	if len(points) != 1 || points[0].Value != 2 || !points[0].Start.Equal(time.Date(2021, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("wrong daily series in UTC: %v", points)
+	}
+
+	// task 110 is finished on the next day in UTC+3
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	points, err = store.TimeSeries(ctx, &models.SeriesFilter{
+		Final: finalStates, Interval: models.IntervalDay, Metric: models.MetricAvgLag, Location: time.FixedZone("Etc/GMT-3", 3*60*60), From: from, To: to,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error on getting time series: %v", err)
+	}
+	if len(points) != 2 || points[0].Value != int64(2*time.Hour) || !points[1].Start.Equal(time.Date(2021, 3, 11, 0, 0, 0, 0, loc)) {
+		t.Fatalf("wrong daily series in UTC+3: %v", points)
+	}
+}
+
+func TestAddTotals(t *testing.T) {
+	ctx := context.TODO()
+	before, _, err := store.GetAggregates(ctx, &models.DelayFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error on getting totals: %v", err)
+	}
+
+	err = store.Atomic(ctx, func(tx ports.EventStorage) error {
+		if err := tx.AddTotals(ctx, 1, 0); err != nil {
+			return err
+		}
+		return tx.AddTotals(ctx, -1, 1)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error on updating totals: %v", err)
+	}
+
+	after, _, err := store.GetAggregates(ctx, &models.DelayFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error on getting totals: %v", err)
+	}
+	if after.Finished != before.Finished || after.Declined != before.Declined+1 {
+		t.Fatalf("wrong totals: before %v, after %v", *before, *after)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	ctx := context.TODO()
+	msg := models.RejectedMessage{
+		Topic:     "test-topic",
+		Partition: 1,
+		Offset:    42,
+		Payload:   []byte(`{"eventtype":"UNKNOWN"}`),
+		Error:     "not classified as valid",
+	}
+
+	if err := store.Reject(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on reject: %v", err)
+	}
+
+	messages, err := store.ListRejected(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error on listing rejected messages: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != msg.ID || string(messages[0].Payload) != string(msg.Payload) {
+		t.Fatalf("wrong rejected messages: expected %v, got %v", msg, messages)
+	}
+
+	if err := store.MarkReplayed(ctx, msg.ID); err != nil {
+		t.Fatalf("unexpected error on marking replayed: %v", err)
+	}
+
+	gotMsg, err := store.GetRejected(ctx, msg.ID)
+	if err != nil {
+		t.Fatalf("unexpected error on getting rejected message: %v", err)
+	}
+	if gotMsg == nil || gotMsg.ReplayedAt == nil {
+		t.Fatalf("message has not been marked as replayed: %v", gotMsg)
+	}
+
+	messages, err = store.ListRejected(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error on listing rejected messages: %v", err)
+	}
+	if len(messages) != 0 {
+		t.Fatalf("replayed message is still listed: %v", messages)
+	}
+}
+
+// runs the last as every conformance test starts from an empty schema
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) ports.EventStorage {
+		ctx := context.TODO()
+		if err := store.Drop(ctx); err != nil {
+			t.Fatalf("error dropping schema: %v", err)
+		}
+		if err := store.Init(ctx); err != nil {
+			t.Fatalf("error creating schema: %v", err)
+		}
+		return store
+	})
+}
+
 func clearDB() {
 	ctx := context.TODO()
 	query := `
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+var _ ports.SLAStorage = &Store{}
+
+// PendingApprovals extracts last events of tasks waiting in one of states since before sentBefore
+func (s *Store) PendingApprovals(ctx context.Context, sentBefore time.Time, states []string) ([]models.Event, error) {
+	query := `SELECT e.id, e.event_type, e.task_id, e.approver_email, e.recieved_at, e.delay, e.total_delay
+	FROM analytics.tasks t JOIN analytics.events e ON e.id = t.event_id
+	WHERE e.event_type = ANY($2) AND e.recieved_at < $1
+	ORDER BY e.recieved_at`
+	rows, err := s.Pool.Query(ctx, query, sentBefore, states)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting pending approvals: %v", err)
+	}
+	defer rows.Close()
+
+	events := make([]models.Event, 0)
+	for rows.Next() {
+		var evt models.Event
+		err = rows.Scan(&evt.ID, &evt.EventType, &evt.TaskID, &evt.Approver, &evt.RecievedAt, &evt.Delay, &evt.TotalDelay)
+		if err != nil {
+			return nil, fmt.Errorf("error reading pending approvals: %v", err)
+		}
+		events = append(events, evt)
+	}
+
+	return events, rows.Err()
+}
+
+// RecordBreaches stores breaches skipping the ones recorded before for the same task and sending time
+func (s *Store) RecordBreaches(ctx context.Context, breaches []models.Breach) ([]models.Breach, error) {
+	query := `INSERT INTO analytics.sla_breaches (task_id, approver_email, sent_at, sla_limit, detected_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (task_id, sent_at) DO NOTHING`
+
+	recorded := make([]models.Breach, 0)
+	for _, b := range breaches {
+		tag, err := s.Pool.Exec(ctx, query, b.TaskID, b.Approver, b.SentAt, b.Limit, b.DetectedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error inserting SLA breach of task %d: %v", b.TaskID, err)
+		}
+		if tag.RowsAffected() != 0 {
+			recorded = append(recorded, b)
+		}
+	}
+
+	return recorded, nil
+}
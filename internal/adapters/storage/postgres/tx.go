@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+// querier is implemented by both the pool and a transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// db returns the transaction of the store if any, otherwise the pool
+func (s *Store) db() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.Pool
+}
+
+// Atomic runs fn in a transaction, the store passed to fn works within the transaction and
+// locks selected tasks. The transaction is committed if fn succeeds, errors of fn are returned as is.
+// A nested call joins the running transaction
+func (s *Store) Atomic(ctx context.Context, fn func(tx ports.EventStorage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := fn(&Store{Pool: s.Pool, tx: tx}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/adapters/storage/calc"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// GetTotals reads numbers of finished and declined tasks maintained by writers
+func (s *Store) GetTotals(ctx context.Context) (*models.Totals, error) {
+	var totals models.Totals
+	query := `SELECT finished, declined FROM totals WHERE id=0`
+	if err := s.db().QueryRowContext(ctx, query).Scan(&totals.Finished, &totals.Declined); err != nil {
+		return nil, fmt.Errorf("error reading totals (finished and declined tasks): %v", err)
+	}
+
+	return &totals, nil
+}
+
+// GetAggregates extracts statistics about finished and declined tasks and its delay.
+// Delays of tasks in filter.Final states are filtered and paginated by the filter, nil filter selects no delays
+func (s *Store) GetAggregates(ctx context.Context, filter *models.DelayFilter) (*models.Totals, []models.Delay, error) {
+	totals, err := s.GetTotals(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query, args := delaysQuery(filter)
+	rows, err := s.db().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error selecting task delays: %v", err)
+	}
+	defer rows.Close()
+
+	delays := make([]models.Delay, 0)
+	for rows.Next() {
+		var (
+			delay models.Delay
+			lag   int64
+		)
+		if err = rows.Scan(&delay.ID, &lag); err != nil {
+			return nil, nil, fmt.Errorf("error reading task delays: %v", err)
+		}
+		delay.Lag = time.Duration(lag)
+		delays = append(delays, delay)
+	}
+
+	return totals, delays, rows.Err()
+}
+
+// delaysQuery composes a query selecting delays of tasks in a final state with the filter applied
+func delaysQuery(filter *models.DelayFilter) (string, []interface{}) {
+	if filter == nil {
+		filter = &models.DelayFilter{}
+	}
+
+	cond, args := finalStateCondition(filter.Final, "", filter.From, filter.To)
+	query := `SELECT t.task_id, e.total_delay
+	FROM tasks t JOIN events e ON e.id = t.event_id
+	WHERE ` + cond
+
+	order, cmp := "ASC", ">"
+	if filter.Desc {
+		order, cmp = "DESC", "<"
+	}
+	if filter.Cursor != 0 {
+		args = append(args, filter.Cursor)
+		query += fmt.Sprintf(" AND t.task_id %s ?", cmp)
+	}
+	query += " ORDER BY t.task_id " + order
+	if filter.Limit != 0 {
+		args = append(args, filter.Limit)
+		query += " LIMIT ?"
+	}
+
+	return query, args
+}
+
+// finalStateCondition composes a condition on the last event e of tasks in one of final states.
+// eventType narrows down the final state, from and to bound the time the state is reached
+func finalStateCondition(final []string, eventType string, from, to time.Time) (string, []interface{}) {
+	cond, args := inStates("e.event_type", final)
+	if eventType != "" {
+		args = append(args, eventType)
+		cond += " AND e.event_type = ?"
+	}
+	if !from.IsZero() {
+		args = append(args, from.UnixNano())
+		cond += " AND e.recieved_at >= ?"
+	}
+	if !to.IsZero() {
+		args = append(args, to.UnixNano())
+		cond += " AND e.recieved_at < ?"
+	}
+
+	return cond, args
+}
+
+// inStates composes a condition checking the column is one of states, SQLite has no arrays
+// so every state is a parameter
+func inStates(column string, states []string) (string, []interface{}) {
+	if len(states) == 0 {
+		return "0", make([]interface{}, 0, 4)
+	}
+
+	args := make([]interface{}, 0, len(states)+4)
+	for _, state := range states {
+		args = append(args, state)
+	}
+	return column + " IN (?" + strings.Repeat(", ?", len(states)-1) + ")", args
+}
+
+// DelayStats calculates statistics and a histogram on delays of tasks in a final state.
+// SQLite has no percentiles, so delays are sorted by the query and counted in Go
+func (s *Store) DelayStats(ctx context.Context, filter *models.StatsFilter) (*models.DelayStats, error) {
+	if filter == nil {
+		filter = &models.StatsFilter{}
+	}
+
+	cond, args := finalStateCondition(filter.Final, filter.EventType, filter.From, filter.To)
+	query := `SELECT e.total_delay FROM tasks t JOIN events e ON e.id = t.event_id
+	WHERE ` + cond + ` ORDER BY e.total_delay`
+	lags, err := s.lags(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating delay statistics: %v", err)
+	}
+
+	return calc.DelayStats(lags, filter.Buckets), nil
+}
+
+// lags runs a query selecting delays in nanoseconds and returns them in seconds
+func (s *Store) lags(ctx context.Context, query string, args ...interface{}) ([]float64, error) {
+	rows, err := s.db().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lags := make([]float64, 0)
+	for rows.Next() {
+		var lag int64
+		if err := rows.Scan(&lag); err != nil {
+			return nil, err
+		}
+		lags = append(lags, time.Duration(lag).Seconds())
+	}
+
+	return lags, rows.Err()
+}
+
+// ApproverStats extracts response statistics grouped by approver,
+// empty email means all approvers
+func (s *Store) ApproverStats(ctx context.Context, email string, states models.ResponseStates) ([]models.ApproverStats, error) {
+	byEmail := make(map[string]*models.ApproverStats)
+	get := func(approver string) *models.ApproverStats {
+		st, ok := byEmail[approver]
+		if !ok {
+			st = &models.ApproverStats{Email: approver}
+			byEmail[approver] = st
+		}
+		return st
+	}
+
+	approved := make(map[string]struct{}, len(states.Approved))
+	for _, state := range states.Approved {
+		approved[state] = struct{}{}
+	}
+
+	cond, args := inStates("event_type", append(append([]string(nil), states.Approved...), states.Declined...))
+	query := `SELECT approver_email, event_type, delay FROM events
+	WHERE ` + cond + ` AND (? = '' OR approver_email = ?)
+	ORDER BY approver_email, delay`
+	rows, err := s.db().QueryContext(ctx, query, append(args, email, email)...)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting approver statistics: %v", err)
+	}
+	defer rows.Close()
+
+	lags := make(map[string][]float64)
+	for rows.Next() {
+		var (
+			approver, eventType string
+			delay               int64
+		)
+		if err := rows.Scan(&approver, &eventType, &delay); err != nil {
+			return nil, fmt.Errorf("error reading approver statistics: %v", err)
+		}
+		if _, ok := approved[eventType]; ok {
+			get(approver).Approved++
+		} else {
+			get(approver).Declined++
+		}
+		lags[approver] = append(lags[approver], time.Duration(delay).Seconds())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading approver statistics: %v", err)
+	}
+
+	cond, args = inStates("e.event_type", states.Awaiting)
+	query = `SELECT e.approver_email, count(*) FROM tasks t JOIN events e ON e.id = t.event_id
+	WHERE ` + cond + ` AND (? = '' OR e.approver_email = ?)
+	GROUP BY e.approver_email`
+	pending, err := s.db().QueryContext(ctx, query, append(args, email, email)...)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting pending tasks of approvers: %v", err)
+	}
+	defer pending.Close()
+
+	for pending.Next() {
+		var approver string
+		var count uint64
+		if err := pending.Scan(&approver, &count); err != nil {
+			return nil, fmt.Errorf("error reading pending tasks of approvers: %v", err)
+		}
+		get(approver).Pending = count
+	}
+	if err := pending.Err(); err != nil {
+		return nil, fmt.Errorf("error reading pending tasks of approvers: %v", err)
+	}
+
+	stats := make([]models.ApproverStats, 0, len(byEmail))
+	for approver, st := range byEmail {
+		if l := lags[approver]; len(l) != 0 {
+			st.AvgLag = calc.Seconds(calc.Mean(l))
+			st.MedianLag, st.P95Lag = calc.Seconds(calc.Percentile(l, 0.5)), calc.Seconds(calc.Percentile(l, 0.95))
+		}
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Email < stats[j].Email
+	})
+
+	return stats, nil
+}
+
+// TimeSeries groups tasks in a final state into buckets, bounds of buckets are calculated in Go
+// as SQLite knows no time zones but UTC and the local one. Buckets without tasks are omitted
+func (s *Store) TimeSeries(ctx context.Context, filter *models.SeriesFilter) ([]models.Point, error) {
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var cond string
+	var args []interface{}
+	switch filter.Metric {
+	case models.MetricFinished:
+		cond, args = finalStateCondition(filter.Final, models.Finished, filter.From, filter.To)
+	case models.MetricDeclined:
+		cond, args = finalStateCondition(filter.Final, "", filter.From, filter.To)
+		args = append(args, models.Finished)
+		cond += " AND e.event_type <> ?"
+	case models.MetricAvgLag:
+		cond, args = finalStateCondition(filter.Final, "", filter.From, filter.To)
+	default:
+		return nil, fmt.Errorf("unknown time series metric %s", filter.Metric)
+	}
+
+	query := `SELECT e.recieved_at, e.total_delay FROM tasks t JOIN events e ON e.id = t.event_id
+	WHERE ` + cond
+	rows, err := s.db().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting time series: %v", err)
+	}
+	defer rows.Close()
+
+	lags := make(map[time.Time][]float64)
+	for rows.Next() {
+		var recievedAt, totalDelay int64
+		if err := rows.Scan(&recievedAt, &totalDelay); err != nil {
+			return nil, fmt.Errorf("error reading time series: %v", err)
+		}
+		start, err := calc.Truncate(unixNano(recievedAt).In(loc), filter.Interval)
+		if err != nil {
+			return nil, err
+		}
+		lags[start] = append(lags[start], time.Duration(totalDelay).Seconds())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading time series: %v", err)
+	}
+
+	points := make([]models.Point, 0, len(lags))
+	for start, l := range lags {
+		p := models.Point{Start: start, Value: int64(len(l))}
+		if filter.Metric == models.MetricAvgLag {
+			p.Value = int64(calc.Seconds(calc.Mean(l)))
+		}
+		points = append(points, p)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Start.Before(points[j].Start)
+	})
+
+	return points, nil
+}
+
+// PendingStats counts tasks in every one of states, states without tasks are omitted
+func (s *Store) PendingStats(ctx context.Context, now time.Time, states []string) ([]models.PendingState, error) {
+	cond, args := inStates("e.event_type", states)
+	query := `SELECT e.event_type, count(*), avg(? - e.recieved_at), max(? - e.recieved_at)
+	FROM tasks t JOIN events e ON e.id = t.event_id
+	WHERE ` + cond + `
+	GROUP BY e.event_type`
+	rows, err := s.db().QueryContext(ctx, query, append([]interface{}{now.UnixNano(), now.UnixNano()}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting pending tasks: %v", err)
+	}
+	defer rows.Close()
+
+	byState := make(map[string]models.PendingState)
+	for rows.Next() {
+		var (
+			st     models.PendingState
+			avg    float64
+			maxAge int64
+		)
+		if err := rows.Scan(&st.State, &st.Count, &avg, &maxAge); err != nil {
+			return nil, fmt.Errorf("error reading pending tasks: %v", err)
+		}
+		st.AvgAge, st.MaxAge = time.Duration(math.Round(avg)), time.Duration(maxAge)
+		byState[st.State] = st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading pending tasks: %v", err)
+	}
+
+	// states are ordered like the given ones
+	stats := make([]models.PendingState, 0, len(byState))
+	for _, state := range states {
+		if st, ok := byState[state]; ok {
+			stats = append(stats, st)
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// Reject stores a rejected message, msg.ID and msg.RejectedAt are set by the store
+func (s *Store) Reject(ctx context.Context, msg *models.RejectedMessage) error {
+	rejectedAt := time.Now().UTC()
+	query := `INSERT INTO rejected_messages (topic, kafka_partition, kafka_offset, payload, error, rejected_at)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	res, err := s.db().ExecContext(ctx, query,
+		msg.Topic,
+		msg.Partition,
+		msg.Offset,
+		msg.Payload,
+		msg.Error,
+		rejectedAt.UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting rejected message in db: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error getting rejected message id: %v", err)
+	}
+	msg.ID, msg.RejectedAt = uint64(id), rejectedAt
+
+	return nil
+}
+
+// ListRejected extracts messages that have not been replayed yet with ID greater than cursor
+func (s *Store) ListRejected(ctx context.Context, cursor, limit uint64) ([]models.RejectedMessage, error) {
+	query := `SELECT id, topic, kafka_partition, kafka_offset, payload, error, rejected_at, replayed_at
+	FROM rejected_messages WHERE replayed_at IS NULL AND id > ? ORDER BY id LIMIT ?`
+	rows, err := s.db().QueryContext(ctx, query, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting rejected messages: %v", err)
+	}
+	defer rows.Close()
+
+	messages := make([]models.RejectedMessage, 0)
+	for rows.Next() {
+		msg, err := scanRejected(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error reading rejected messages: %v", err)
+		}
+		messages = append(messages, *msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// GetRejected extracts a rejected message by its ID, nil means there is no such message
+func (s *Store) GetRejected(ctx context.Context, ID uint64) (*models.RejectedMessage, error) {
+	query := `SELECT id, topic, kafka_partition, kafka_offset, payload, error, rejected_at, replayed_at
+	FROM rejected_messages WHERE id=?`
+	msg, err := scanRejected(s.db().QueryRowContext(ctx, query, ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error selecting rejected message: %v", err)
+	}
+
+	return msg, nil
+}
+
+// MarkReplayed notes that the rejected message has been processed successfully
+func (s *Store) MarkReplayed(ctx context.Context, ID uint64) error {
+	query := `UPDATE rejected_messages SET replayed_at=? WHERE id=?`
+	_, err := s.db().ExecContext(ctx, query, time.Now().UnixNano(), ID)
+	return err
+}
+
+// scanRejected reads columns of rejected_messages
+func scanRejected(row scanner) (*models.RejectedMessage, error) {
+	var (
+		msg        models.RejectedMessage
+		rejectedAt int64
+		replayedAt sql.NullInt64
+	)
+	err := row.Scan(&msg.ID, &msg.Topic, &msg.Partition, &msg.Offset, &msg.Payload, &msg.Error, &rejectedAt, &replayedAt)
+	if err != nil {
+		return nil, err
+	}
+	msg.RejectedAt = unixNano(rejectedAt)
+	if replayedAt.Valid {
+		t := unixNano(replayedAt.Int64)
+		msg.ReplayedAt = &t
+	}
+
+	return &msg, nil
+}
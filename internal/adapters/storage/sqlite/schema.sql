@@ -0,0 +1,59 @@
+-- times are unix times in nanoseconds, delays are nanoseconds
+
+-- events is an append-only log, every accepted message adds a row
+-- delay is the lag caused by the event, total_delay is the lag accumulated by the task
+CREATE TABLE IF NOT EXISTS events
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	task_id INTEGER NOT NULL,
+	event_type TEXT NOT NULL,
+	approver_email TEXT NOT NULL,
+	recieved_at INTEGER NOT NULL,
+	delay INTEGER NOT NULL DEFAULT 0,
+	total_delay INTEGER NOT NULL DEFAULT 0,
+	message_id TEXT DEFAULT NULL
+);
+CREATE INDEX IF NOT EXISTS events_task_id_idx ON events (task_id, id);
+CREATE UNIQUE INDEX IF NOT EXISTS events_message_id_idx ON events (message_id) WHERE message_id IS NOT NULL;
+
+-- tasks points to the last event of every task
+CREATE TABLE IF NOT EXISTS tasks
+(
+	task_id INTEGER PRIMARY KEY,
+	event_id INTEGER NOT NULL REFERENCES events (id)
+);
+
+-- totals keeps a single row maintained by writers
+CREATE TABLE IF NOT EXISTS totals
+(
+	id INTEGER PRIMARY KEY,
+	finished INTEGER NOT NULL DEFAULT 0,
+	declined INTEGER NOT NULL DEFAULT 0
+);
+INSERT OR IGNORE INTO totals (id) VALUES (0);
+
+-- rejected_messages is a dead-letter queue of messages that failed parsing or processing
+CREATE TABLE IF NOT EXISTS rejected_messages
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	topic TEXT NOT NULL,
+	kafka_partition INTEGER NOT NULL,
+	kafka_offset INTEGER NOT NULL,
+	payload BLOB NOT NULL,
+	error TEXT NOT NULL,
+	rejected_at INTEGER NOT NULL,
+	replayed_at INTEGER DEFAULT NULL
+);
+
+-- sla_breaches keeps tasks detected waiting for the approver longer than allowed
+CREATE TABLE IF NOT EXISTS sla_breaches
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	task_id INTEGER NOT NULL,
+	approver_email TEXT NOT NULL,
+	sent_at INTEGER NOT NULL,
+	sla_limit INTEGER NOT NULL,
+	detected_at INTEGER NOT NULL,
+
+	UNIQUE (task_id, sent_at)
+);
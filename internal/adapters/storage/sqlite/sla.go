@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// PendingApprovals extracts last events of tasks waiting in one of states since before sentBefore
+func (s *Store) PendingApprovals(ctx context.Context, sentBefore time.Time, states []string) ([]models.Event, error) {
+	cond, args := inStates("e.event_type", states)
+	query := `SELECT e.id, e.event_type, e.task_id, e.approver_email, e.recieved_at, e.delay, e.total_delay
+	FROM tasks t JOIN events e ON e.id = t.event_id
+	WHERE ` + cond + ` AND e.recieved_at < ?
+	ORDER BY e.recieved_at`
+	events, err := s.events(ctx, query, append(args, sentBefore.UnixNano())...)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting pending approvals: %v", err)
+	}
+	return events, nil
+}
+
+// RecordBreaches stores breaches skipping the ones recorded before for the same task and sending time
+func (s *Store) RecordBreaches(ctx context.Context, breaches []models.Breach) ([]models.Breach, error) {
+	query := `INSERT INTO sla_breaches (task_id, approver_email, sent_at, sla_limit, detected_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (task_id, sent_at) DO NOTHING`
+
+	recorded := make([]models.Breach, 0)
+	for _, b := range breaches {
+		res, err := s.db().ExecContext(ctx, query, b.TaskID, b.Approver, b.SentAt.UnixNano(), int64(b.Limit), b.DetectedAt.UnixNano())
+		if err != nil {
+			return nil, fmt.Errorf("error inserting SLA breach of task %d: %v", b.TaskID, err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n != 0 {
+			recorded = append(recorded, b)
+		}
+	}
+
+	return recorded, nil
+}
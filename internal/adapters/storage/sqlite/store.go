@@ -0,0 +1,293 @@
+// Package sqlite keeps analytics in an embedded SQLite database for small deployments
+// and local development, no database server is needed
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+	// registers the pure-Go driver "sqlite"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	_ ports.EventStorage    = &Store{}
+	_ ports.DeadLetterStore = &Store{}
+	_ ports.SLAStorage      = &Store{}
+)
+
+//go:embed schema.sql
+var schema string
+
+// Store keeps analytics in a SQLite database
+type Store struct {
+	DB *sql.DB
+
+	// tx is set on a store passed to Atomic callbacks
+	tx *sql.Tx
+}
+
+// New opens the database file at path, ":memory:" keeps the database in memory.
+// SQLite has a single writer, so statements share one connection and are executed one by one
+func New(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
+		return nil, fmt.Errorf("unable to ping database: %w", err)
+	}
+
+	return &Store{DB: db}, nil
+}
+
+// Init creates tables that do not exist yet, stored data is kept
+func (s *Store) Init(ctx context.Context) error {
+	if _, err := s.DB.ExecContext(ctx, schema); err != nil {
+		return fmt.Errorf("error creating schema: %v", err)
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.DB.Close()
+}
+
+// querier is implemented by both the database and a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// db returns the transaction of the store if any, otherwise the database
+func (s *Store) db() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.DB
+}
+
+// Atomic runs fn in a transaction, the transaction holds the only connection, so
+// transactions are applied one by one. The transaction is committed if fn succeeds,
+// errors of fn are returned as is. A nested call joins the running transaction
+func (s *Store) Atomic(ctx context.Context, fn func(tx ports.EventStorage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Store{DB: s.DB, tx: tx}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// atomic runs fn on the transaction of the store, a new one is started if there is none
+func (s *Store) atomic(ctx context.Context, fn func(tx *Store) error) error {
+	return s.Atomic(ctx, func(tx ports.EventStorage) error {
+		return fn(tx.(*Store))
+	})
+}
+
+// Insert adds event about task that has not been stored yet, an existing task fails on the primary key
+func (s *Store) Insert(ctx context.Context, msg *models.Message) error {
+	return s.atomic(ctx, func(tx *Store) error {
+		id, err := tx.insertEvent(ctx, msg, 0, 0)
+		if err != nil {
+			return fmt.Errorf("error inserting new event in db: %v", err)
+		}
+
+		_, err = tx.db().ExecContext(ctx, `INSERT INTO tasks (task_id, event_id) VALUES (?, ?)`, msg.TaskID, id)
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+			return fmt.Errorf("%w: error inserting new task in db: %v", ports.ErrTaskExists, err)
+		}
+		if err != nil {
+			return fmt.Errorf("error inserting new task in db: %v", err)
+		}
+		return nil
+	})
+}
+
+// Select extracts the current state of a task with specified ID, that is the last event of the task
+func (s *Store) Select(ctx context.Context, taskID uint64) (*models.Event, error) {
+	query := `SELECT e.id, e.event_type, e.task_id, e.approver_email, e.recieved_at, e.delay, e.total_delay
+	FROM tasks t JOIN events e ON e.id = t.event_id
+	WHERE t.task_id=?`
+	evt, err := scanEvent(s.db().QueryRowContext(ctx, query, taskID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error selecting task: %v", err)
+	}
+
+	return evt, nil
+}
+
+// Update appends an event about particular task with msg values, the accumulated delay is kept
+func (s *Store) Update(ctx context.Context, msg *models.Message) error {
+	return s.appendEvent(ctx, msg, false)
+}
+
+// UpdateDelay appends an event about particular task with msg values and the delay
+// calculated since the previous event
+func (s *Store) UpdateDelay(ctx context.Context, msg *models.Message) error {
+	return s.appendEvent(ctx, msg, true)
+}
+
+// appendEvent adds a new event to the task log and moves the task to it, with withDelay
+// the time passed since the previous event is added to the accumulated delay
+func (s *Store) appendEvent(ctx context.Context, msg *models.Message, withDelay bool) error {
+	return s.atomic(ctx, func(tx *Store) error {
+		var delay, totalDelay time.Duration
+		prev, err := tx.Select(ctx, msg.TaskID)
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			if withDelay {
+				delay = msg.RecievedAt.Sub(prev.RecievedAt)
+			}
+			totalDelay = prev.TotalDelay + delay
+		}
+
+		id, err := tx.insertEvent(ctx, msg, delay, totalDelay)
+		if err != nil {
+			return fmt.Errorf("error appending event in db: %v", err)
+		}
+
+		_, err = tx.db().ExecContext(ctx, `INSERT INTO tasks (task_id, event_id) VALUES (?, ?)
+		ON CONFLICT (task_id) DO UPDATE SET event_id = excluded.event_id`, msg.TaskID, id)
+		if err != nil {
+			return fmt.Errorf("error moving task in db: %v", err)
+		}
+		return nil
+	})
+}
+
+// insertEvent adds a row to the event log and returns its id
+func (s *Store) insertEvent(ctx context.Context, msg *models.Message, delay, totalDelay time.Duration) (int64, error) {
+	query := `INSERT INTO events (task_id, event_type, approver_email, recieved_at, delay, total_delay, message_id)
+	VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''))`
+	res, err := s.db().ExecContext(ctx, query,
+		msg.TaskID,
+		msg.EventType,
+		msg.Approver,
+		msg.RecievedAt.UnixNano(),
+		int64(delay),
+		int64(totalDelay),
+		msg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Seen reports whether an event with the message id has already been stored
+func (s *Store) Seen(ctx context.Context, messageID string) (bool, error) {
+	var seen bool
+	query := `SELECT EXISTS (SELECT 1 FROM events WHERE message_id=?)`
+	if err := s.db().QueryRowContext(ctx, query, messageID).Scan(&seen); err != nil {
+		return false, fmt.Errorf("error checking message id: %v", err)
+	}
+	return seen, nil
+}
+
+// AddTotals changes counters of tasks in a final state
+func (s *Store) AddTotals(ctx context.Context, finished, declined int64) error {
+	query := `UPDATE totals SET finished = finished + ?, declined = declined + ? WHERE id = 0`
+	if _, err := s.db().ExecContext(ctx, query, finished, declined); err != nil {
+		return fmt.Errorf("error updating totals: %v", err)
+	}
+	return nil
+}
+
+// RecountTotals replaces counters by numbers of tasks in finished and in declined states
+func (s *Store) RecountTotals(ctx context.Context, finished, declined []string) error {
+	finishedCond, args := inStates("e.event_type", finished)
+	declinedCond, declinedArgs := inStates("e.event_type", declined)
+	query := `UPDATE totals SET
+		finished = (SELECT count(*) FROM tasks t JOIN events e ON e.id = t.event_id WHERE ` + finishedCond + `),
+		declined = (SELECT count(*) FROM tasks t JOIN events e ON e.id = t.event_id WHERE ` + declinedCond + `)
+	WHERE id = 0`
+	if _, err := s.db().ExecContext(ctx, query, append(args, declinedArgs...)...); err != nil {
+		return fmt.Errorf("error recounting totals: %v", err)
+	}
+	return nil
+}
+
+// History extracts all events of the task in the order they have been stored
+func (s *Store) History(ctx context.Context, taskID uint64) ([]models.Event, error) {
+	query := `SELECT id, event_type, task_id, approver_email, recieved_at, delay, total_delay
+	FROM events WHERE task_id=? ORDER BY id`
+	return s.events(ctx, query, taskID)
+}
+
+// events runs a query selecting events
+func (s *Store) events(ctx context.Context, query string, args ...interface{}) ([]models.Event, error) {
+	rows, err := s.db().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting events: %v", err)
+	}
+	defer rows.Close()
+
+	events := make([]models.Event, 0)
+	for rows.Next() {
+		evt, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error reading events: %v", err)
+		}
+		events = append(events, *evt)
+	}
+
+	return events, rows.Err()
+}
+
+// scanner is implemented by both sql.Row and sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEvent reads id, event_type, task_id, approver_email, recieved_at, delay and total_delay columns
+func scanEvent(row scanner) (*models.Event, error) {
+	var (
+		evt               models.Event
+		recievedAt        int64
+		delay, totalDelay int64
+	)
+	err := row.Scan(&evt.ID, &evt.EventType, &evt.TaskID, &evt.Approver, &recievedAt, &delay, &totalDelay)
+	if err != nil {
+		return nil, err
+	}
+	evt.RecievedAt = unixNano(recievedAt)
+	evt.Delay, evt.TotalDelay = time.Duration(delay), time.Duration(totalDelay)
+
+	return &evt, nil
+}
+
+// unixNano converts a stored time to time.Time in UTC
+func unixNano(ns int64) time.Time {
+	return time.Unix(0, ns).UTC()
+}
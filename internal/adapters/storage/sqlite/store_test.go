@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/adapters/storage/storagetest"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+// newStore opens a new database in a temporary directory
+func newStore(t *testing.T) *Store {
+	store, err := New(filepath.Join(t.TempDir(), "analytics.db"))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.Init(context.TODO()); err != nil {
+		t.Fatalf("error creating schema: %v", err)
+	}
+	return store
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) ports.EventStorage {
+		return newStore(t)
+	})
+}
+
+func TestReopen(t *testing.T) {
+	ctx := context.TODO()
+	path := filepath.Join(t.TempDir(), "analytics.db")
+	msg := models.Message{EventType: models.Created, TaskID: 1, RecievedAt: time.Date(2022, 8, 1, 12, 0, 0, 1, time.UTC)}
+
+	store, err := New(path)
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("error creating schema: %v", err)
+	}
+	if err := store.Insert(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on insert: %v", err)
+	}
+	if err := store.AddTotals(ctx, 1, 0); err != nil {
+		t.Fatalf("unexpected error on adding totals: %v", err)
+	}
+	store.Close()
+
+	// the schema is created once, data is kept
+	store, err = New(path)
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	defer store.Close()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("error creating schema: %v", err)
+	}
+
+	evt, err := store.Select(ctx, 1)
+	if err != nil || evt == nil || !evt.RecievedAt.Equal(msg.RecievedAt) {
+		t.Fatalf("stored task has been lost: %v, %v", evt, err)
+	}
+	totals, _, err := store.GetAggregates(ctx, nil)
+	if err != nil || totals.Finished != 1 {
+		t.Fatalf("stored totals have been lost: %v, %v", totals, err)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	ctx := context.TODO()
+	store := newStore(t)
+	msg := models.RejectedMessage{Topic: "test-topic", Partition: 1, Offset: 42, Payload: []byte(`{}`), Error: "bad message"}
+
+	if err := store.Reject(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on reject: %v", err)
+	}
+	messages, err := store.ListRejected(ctx, 0, 10)
+	if err != nil || len(messages) != 1 || messages[0].ID != msg.ID || string(messages[0].Payload) != "{}" {
+		t.Fatalf("wrong rejected messages: %v, %v", messages, err)
+	}
+
+	if err := store.MarkReplayed(ctx, msg.ID); err != nil {
+		t.Fatalf("unexpected error on marking replayed: %v", err)
+	}
+	got, err := store.GetRejected(ctx, msg.ID)
+	if err != nil || got == nil || got.ReplayedAt == nil {
+		t.Fatalf("message has not been marked as replayed: %v, %v", got, err)
+	}
+	if got, err := store.GetRejected(ctx, 42); err != nil || got != nil {
+		t.Fatalf("unknown message has been found: %v, %v", got, err)
+	}
+}
+
+func TestSLABreaches(t *testing.T) {
+	ctx := context.TODO()
+	store := newStore(t)
+	sentAt := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	for _, msg := range []models.Message{
+		{EventType: models.Created, TaskID: 1, RecievedAt: sentAt.Add(-time.Minute)},
+		{EventType: models.MessageSent, TaskID: 1, Approver: "approver@mail.com", RecievedAt: sentAt},
+	} {
+		msg := msg
+		if err := store.Update(ctx, &msg); err != nil {
+			t.Fatalf("unexpected error on storing message: %v", err)
+		}
+	}
+
+	pending, err := store.PendingApprovals(ctx, sentAt.Add(time.Second), []string{models.MessageSent})
+	if err != nil || len(pending) != 1 || pending[0].TaskID != 1 {
+		t.Fatalf("wrong pending approvals: %v, %v", pending, err)
+	}
+
+	breach := models.Breach{TaskID: 1, Approver: "approver@mail.com", SentAt: sentAt, Limit: time.Hour, DetectedAt: sentAt.Add(2 * time.Hour)}
+	recorded, err := store.RecordBreaches(ctx, []models.Breach{breach})
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("breach has not been recorded: %v, %v", recorded, err)
+	}
+	recorded, err = store.RecordBreaches(ctx, []models.Breach{breach})
+	if err != nil || len(recorded) != 0 {
+		t.Fatalf("breach has been recorded twice: %v, %v", recorded, err)
+	}
+}
@@ -0,0 +1,497 @@
+// Package storagetest is a conformance suite of ports.EventStorage. A backend runs it against itself:
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) ports.EventStorage { return New() })
+//	}
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+// Factory gives an empty storage to a test
+type Factory func(t *testing.T) ports.EventStorage
+
+// timeStamp is a base of message times, whole seconds survive any timestamp precision
+var timeStamp = time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+
+// final and pending states of the default transition table, storages are given them by filters
+var (
+	final   = []string{models.Declined, models.Finished, models.Deleted}
+	pending = []string{models.Created, models.MessageSent, models.Approved}
+)
+
+// Run runs every conformance test on a separate storage given by newStorage
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, db ports.EventStorage)
+	}{
+		{name: "InsertSelect", test: testInsertSelect},
+		{name: "Update", test: testUpdate},
+		{name: "UpdateDelay", test: testUpdateDelay},
+		{name: "History", test: testHistory},
+		{name: "Seen", test: testSeen},
+		{name: "Atomic", test: testAtomic},
+		{name: "Totals", test: testTotals},
+		{name: "RecountTotals", test: testRecountTotals},
+		{name: "GetAggregatesEmpty", test: testGetAggregatesEmpty},
+		{name: "GetAggregates", test: testGetAggregates},
+		{name: "DelayStats", test: testDelayStats},
+		{name: "PendingStats", test: testPendingStats},
+		{name: "ApproverStats", test: testApproverStats},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+// task stores messages moving a task through states CREATED, MESSAGE_SENT and finalState
+// if it is set, the final state is reached lag after the task has been sent
+func task(t *testing.T, db ports.EventStorage, taskID uint64, sentAt time.Time, lag time.Duration, finalState string) {
+	t.Helper()
+	ctx := context.TODO()
+
+	msgs := []models.Message{
+		{EventType: models.Created, TaskID: taskID, RecievedAt: sentAt.Add(-time.Minute)},
+		{EventType: models.MessageSent, TaskID: taskID, Approver: "approver@mail.com", RecievedAt: sentAt},
+	}
+	for i, msg := range msgs {
+		var err error
+		if i == 0 {
+			err = db.Insert(ctx, &msg)
+		} else {
+			err = db.Update(ctx, &msg)
+		}
+		if err != nil {
+			t.Fatalf("error storing message %v: %v", msg, err)
+		}
+	}
+
+	if finalState == "" {
+		return
+	}
+	msg := models.Message{EventType: finalState, TaskID: taskID, Approver: "approver@mail.com", RecievedAt: sentAt.Add(lag)}
+	if err := db.UpdateDelay(ctx, &msg); err != nil {
+		t.Fatalf("error storing message %v: %v", msg, err)
+	}
+}
+
+// sameEvent reports whether events are equal ignoring IDs and time zones
+func sameEvent(a, b models.Event) bool {
+	return a.EventType == b.EventType && a.TaskID == b.TaskID && a.Approver == b.Approver &&
+		a.RecievedAt.Equal(b.RecievedAt) && a.Delay == b.Delay && a.TotalDelay == b.TotalDelay
+}
+
+func testInsertSelect(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+	msg := models.Message{EventType: models.Created, TaskID: 1, Approver: "", RecievedAt: timeStamp}
+
+	if err := db.Insert(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on insert: %v", err)
+	}
+
+	evt, err := db.Select(ctx, msg.TaskID)
+	if err != nil {
+		t.Fatalf("unexpected error on select: %v", err)
+	}
+	expected := models.Event{EventType: models.Created, TaskID: 1, RecievedAt: timeStamp}
+	if evt == nil || !sameEvent(*evt, expected) {
+		t.Fatalf("expected %v, got %v", expected, evt)
+	}
+
+	evt, err = db.Select(ctx, 2)
+	if err != nil || evt != nil {
+		t.Fatalf("unknown task has to give nil event and no error, got %v, %v", evt, err)
+	}
+
+	// a task is created once
+	if err := db.Insert(ctx, &msg); !errors.Is(err, ports.ErrTaskExists) {
+		t.Fatalf("expected %v on the second insert, got %v", ports.ErrTaskExists, err)
+	}
+}
+
+func testUpdate(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+	task(t, db, 1, timeStamp, 30*time.Second, models.Approved)
+
+	msg := models.Message{EventType: models.MessageSent, TaskID: 1, Approver: "next@mail.com", RecievedAt: timeStamp.Add(time.Hour)}
+	if err := db.Update(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on update: %v", err)
+	}
+
+	// the event causes no delay, the accumulated one is kept
+	evt, err := db.Select(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error on select: %v", err)
+	}
+	expected := models.Event{EventType: models.MessageSent, TaskID: 1, Approver: "next@mail.com",
+		RecievedAt: timeStamp.Add(time.Hour), TotalDelay: 30 * time.Second}
+	if evt == nil || !sameEvent(*evt, expected) {
+		t.Fatalf("expected %v, got %v", expected, evt)
+	}
+}
+
+func testUpdateDelay(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+	task(t, db, 1, timeStamp, 30*time.Second, models.Approved)
+
+	evt, err := db.Select(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error on select: %v", err)
+	}
+	expected := models.Event{EventType: models.Approved, TaskID: 1, Approver: "approver@mail.com",
+		RecievedAt: timeStamp.Add(30 * time.Second), Delay: 30 * time.Second, TotalDelay: 30 * time.Second}
+	if evt == nil || !sameEvent(*evt, expected) {
+		t.Fatalf("expected %v, got %v", expected, evt)
+	}
+
+	// delays are accumulated by the task
+	msgs := []models.Message{
+		{EventType: models.MessageSent, TaskID: 1, Approver: "next@mail.com", RecievedAt: timeStamp.Add(time.Minute)},
+		{EventType: models.Declined, TaskID: 1, Approver: "next@mail.com", RecievedAt: timeStamp.Add(2 * time.Minute)},
+	}
+	if err := db.Update(ctx, &msgs[0]); err != nil {
+		t.Fatalf("unexpected error on update: %v", err)
+	}
+	if err := db.UpdateDelay(ctx, &msgs[1]); err != nil {
+		t.Fatalf("unexpected error on update with delay: %v", err)
+	}
+
+	evt, err = db.Select(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error on select: %v", err)
+	}
+	expected = models.Event{EventType: models.Declined, TaskID: 1, Approver: "next@mail.com",
+		RecievedAt: timeStamp.Add(2 * time.Minute), Delay: time.Minute, TotalDelay: 90 * time.Second}
+	if evt == nil || !sameEvent(*evt, expected) {
+		t.Fatalf("expected %v, got %v", expected, evt)
+	}
+}
+
+func testHistory(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+	task(t, db, 1, timeStamp, 30*time.Second, models.Approved)
+	task(t, db, 2, timeStamp, 0, "")
+
+	events, err := db.History(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error on history: %v", err)
+	}
+	states := make([]string, 0, len(events))
+	for i, evt := range events {
+		if evt.TaskID != 1 || i > 0 && evt.ID <= events[i-1].ID {
+			t.Fatalf("wrong history: %v", events)
+		}
+		states = append(states, evt.EventType)
+	}
+	if !reflect.DeepEqual(states, []string{models.Created, models.MessageSent, models.Approved}) {
+		t.Fatalf("wrong history: %v", events)
+	}
+
+	events, err = db.History(ctx, 3)
+	if err != nil || events == nil || len(events) != 0 {
+		t.Fatalf("unknown task has to give empty history, got %v, %v", events, err)
+	}
+}
+
+func testSeen(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+	msg := models.Message{ID: "msg-1", EventType: models.Created, TaskID: 1, RecievedAt: timeStamp}
+
+	if err := db.Insert(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on insert: %v", err)
+	}
+
+	seen, err := db.Seen(ctx, msg.ID)
+	if err != nil || !seen {
+		t.Fatalf("stored message id has not been seen: %v, %v", seen, err)
+	}
+	seen, err = db.Seen(ctx, "msg-unknown")
+	if err != nil || seen {
+		t.Fatalf("unknown message id has been seen: %v, %v", seen, err)
+	}
+
+	// a message id is stored once, messages without ids are not restricted
+	if err := db.Update(ctx, &msg); err == nil {
+		t.Fatalf("message with the same id has been stored twice")
+	}
+	for i := 0; i < 2; i++ {
+		msg := models.Message{EventType: models.MessageSent, TaskID: 1, RecievedAt: timeStamp}
+		if err := db.Update(ctx, &msg); err != nil {
+			t.Fatalf("unexpected error on update without message id: %v", err)
+		}
+	}
+}
+
+func testAtomic(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+	msg := models.Message{ID: "msg-1", EventType: models.Created, TaskID: 1, RecievedAt: timeStamp}
+
+	errRollback := errors.New("rollback")
+	err := db.Atomic(ctx, func(tx ports.EventStorage) error {
+		if err := tx.Insert(ctx, &msg); err != nil {
+			return err
+		}
+		if err := tx.AddTotals(ctx, 1, 1); err != nil {
+			return err
+		}
+		// nested calls join the transaction
+		return tx.Atomic(ctx, func(tx ports.EventStorage) error {
+			evt, err := tx.Select(ctx, msg.TaskID)
+			if err != nil || evt == nil {
+				t.Errorf("message has not been seen inside the transaction: %v, %v", evt, err)
+			}
+			return errRollback
+		})
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expected error of the callback, got %v", err)
+	}
+
+	evt, err := db.Select(ctx, msg.TaskID)
+	if err != nil || evt != nil {
+		t.Fatalf("rolled back message has been stored: %v, %v", evt, err)
+	}
+	if seen, err := db.Seen(ctx, msg.ID); err != nil || seen {
+		t.Fatalf("rolled back message id has been seen: %v, %v", seen, err)
+	}
+	totals, err := db.GetTotals(ctx)
+	if err != nil || *totals != (models.Totals{}) {
+		t.Fatalf("rolled back totals have been kept: %v, %v", totals, err)
+	}
+
+	err = db.Atomic(ctx, func(tx ports.EventStorage) error {
+		return tx.Insert(ctx, &msg)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error on insert: %v", err)
+	}
+	if evt, err := db.Select(ctx, msg.TaskID); err != nil || evt == nil {
+		t.Fatalf("committed message has not been stored: %v, %v", evt, err)
+	}
+}
+
+func testTotals(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+
+	for _, delta := range [][2]int64{{1, 0}, {1, 0}, {0, 1}, {-1, 1}} {
+		if err := db.AddTotals(ctx, delta[0], delta[1]); err != nil {
+			t.Fatalf("unexpected error on adding totals: %v", err)
+		}
+	}
+
+	totals, err := db.GetTotals(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error on getting totals: %v", err)
+	}
+	if *totals != (models.Totals{Finished: 1, Declined: 2}) {
+		t.Fatalf("wrong totals: %v", *totals)
+	}
+}
+
+func testRecountTotals(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+	task(t, db, 1, timeStamp, 10*time.Second, models.Finished)
+	task(t, db, 2, timeStamp, 20*time.Second, models.Declined)
+	task(t, db, 3, timeStamp, 0, "")
+	if err := db.AddTotals(ctx, 5, 5); err != nil {
+		t.Fatalf("unexpected error on adding totals: %v", err)
+	}
+
+	tests := []struct {
+		finished, declined []string
+		totals             models.Totals
+	}{
+		{finished: []string{models.Finished}, declined: []string{models.Declined, models.Deleted}, totals: models.Totals{Finished: 1, Declined: 1}},
+		// FINISHED is not a final state of the table
+		{declined: []string{models.Declined}, totals: models.Totals{Declined: 1}},
+	}
+	for _, tt := range tests {
+		if err := db.RecountTotals(ctx, tt.finished, tt.declined); err != nil {
+			t.Fatalf("unexpected error on recounting totals: %v", err)
+		}
+		totals, err := db.GetTotals(ctx)
+		if err != nil || *totals != tt.totals {
+			t.Fatalf("%v, %v: expected totals %v, got %v, %v", tt.finished, tt.declined, tt.totals, totals, err)
+		}
+	}
+}
+
+func testGetAggregatesEmpty(t *testing.T, db ports.EventStorage) {
+	totals, delays, err := db.GetAggregates(context.TODO(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on getting aggregates: %v", err)
+	}
+	if totals == nil || *totals != (models.Totals{}) || delays == nil || len(delays) != 0 {
+		t.Fatalf("empty storage has to give zero totals and no delays, got %v, %v", totals, delays)
+	}
+}
+
+func testGetAggregates(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+	// tasks 1-4 reach final states an hour apart, task 5 is pending
+	task(t, db, 1, timeStamp, 40*time.Second, models.Finished)
+	task(t, db, 2, timeStamp.Add(time.Hour), 30*time.Second, models.Declined)
+	task(t, db, 3, timeStamp.Add(2*time.Hour), 20*time.Second, models.Deleted)
+	task(t, db, 4, timeStamp.Add(3*time.Hour), 10*time.Second, models.Finished)
+	task(t, db, 5, timeStamp, 0, "")
+
+	tests := []struct {
+		name     string
+		filter   *models.DelayFilter
+		expected []uint64
+	}{
+		{name: "nil filter", filter: nil, expected: []uint64{}},
+		{name: "empty filter", filter: &models.DelayFilter{Final: final}, expected: []uint64{1, 2, 3, 4}},
+		{name: "page", filter: &models.DelayFilter{Final: final, Cursor: 1, Limit: 2}, expected: []uint64{2, 3}},
+		{name: "descending", filter: &models.DelayFilter{Final: final, Desc: true, Limit: 3}, expected: []uint64{4, 3, 2}},
+		{name: "descending page", filter: &models.DelayFilter{Final: final, Desc: true, Cursor: 3}, expected: []uint64{2, 1}},
+		{name: "cursor after the last task", filter: &models.DelayFilter{Final: final, Cursor: 4}, expected: []uint64{}},
+		// from is included, to is not
+		{
+			name:     "period",
+			filter:   &models.DelayFilter{Final: final, From: timeStamp.Add(time.Hour + 30*time.Second), To: timeStamp.Add(2*time.Hour + 20*time.Second)},
+			expected: []uint64{2},
+		},
+		// final states come from the transition table, others are not selected
+		{name: "given final states", filter: &models.DelayFilter{Final: []string{models.Declined, models.Deleted}}, expected: []uint64{2, 3}},
+	}
+
+	lags := map[uint64]time.Duration{1: 40 * time.Second, 2: 30 * time.Second, 3: 20 * time.Second, 4: 10 * time.Second}
+	for _, tt := range tests {
+		_, delays, err := db.GetAggregates(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		expected := make([]models.Delay, 0, len(tt.expected))
+		for _, id := range tt.expected {
+			expected = append(expected, models.Delay{ID: id, Lag: lags[id]})
+		}
+		if !reflect.DeepEqual(delays, expected) {
+			t.Fatalf("%s: expected %v, got %v", tt.name, expected, delays)
+		}
+	}
+}
+
+func testDelayStats(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+
+	stats, err := db.DelayStats(ctx, &models.StatsFilter{Final: final, Buckets: 2})
+	if err != nil {
+		t.Fatalf("unexpected error on delay statistics: %v", err)
+	}
+	if stats.Count != 0 || stats.Histogram == nil || len(stats.Histogram) != 0 {
+		t.Fatalf("empty storage has to give no delays, got %+v", *stats)
+	}
+
+	// equal delays are not split
+	task(t, db, 1, timeStamp, 10*time.Second, models.Finished)
+	task(t, db, 2, timeStamp, 10*time.Second, models.Declined)
+	stats, err = db.DelayStats(ctx, &models.StatsFilter{Final: final, Buckets: 4})
+	if err != nil {
+		t.Fatalf("unexpected error on delay statistics: %v", err)
+	}
+	if stats.Count != 2 || !reflect.DeepEqual(stats.Histogram, []models.Bucket{{From: 10 * time.Second, To: 10 * time.Second, Count: 2}}) {
+		t.Fatalf("wrong statistics on equal delays: %+v", *stats)
+	}
+
+	task(t, db, 3, timeStamp, 30*time.Second, models.Finished)
+	task(t, db, 4, timeStamp, 50*time.Second, models.Finished)
+	stats, err = db.DelayStats(ctx, &models.StatsFilter{Final: final, EventType: models.Finished, Buckets: 2})
+	if err != nil {
+		t.Fatalf("unexpected error on delay statistics: %v", err)
+	}
+	expected := models.DelayStats{
+		Count:  3,
+		Min:    10 * time.Second,
+		Max:    50 * time.Second,
+		Mean:   30 * time.Second,
+		Median: 30 * time.Second,
+		P90:    46 * time.Second,
+		P95:    48 * time.Second,
+		P99:    49600 * time.Millisecond,
+		// the max value is counted by the last bucket
+		Histogram: []models.Bucket{
+			{From: 10 * time.Second, To: 30 * time.Second, Count: 1},
+			{From: 30 * time.Second, To: 50 * time.Second, Count: 2},
+		},
+	}
+	if !reflect.DeepEqual(*stats, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, *stats)
+	}
+}
+
+func testPendingStats(t *testing.T, db ports.EventStorage) {
+	ctx := context.TODO()
+	task(t, db, 1, timeStamp, 0, "")
+	task(t, db, 2, timeStamp.Add(time.Minute), 0, "")
+	task(t, db, 3, timeStamp, 10*time.Second, models.Finished)
+
+	stats, err := db.PendingStats(ctx, timeStamp.Add(time.Hour), pending)
+	if err != nil {
+		t.Fatalf("unexpected error on pending statistics: %v", err)
+	}
+	expected := []models.PendingState{{State: models.MessageSent, Count: 2, AvgAge: 59*time.Minute + 30*time.Second, MaxAge: time.Hour}}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Fatalf("expected %v, got %v", expected, stats)
+	}
+
+	// only given states are counted
+	stats, err = db.PendingStats(ctx, timeStamp.Add(time.Hour), []string{models.Created, models.Approved})
+	if err != nil {
+		t.Fatalf("unexpected error on pending statistics: %v", err)
+	}
+	if len(stats) != 0 {
+		t.Fatalf("tasks in states that are not given have been counted: %v", stats)
+	}
+}
+
+func testApproverStats(t *testing.T, db ports.EventStorage) {
+	task(t, db, 1, timeStamp, 10*time.Second, models.Approved)
+	task(t, db, 2, timeStamp, 20*time.Second, models.Declined)
+	task(t, db, 3, timeStamp, 0, "")
+
+	tests := []struct {
+		states   models.ResponseStates
+		expected models.ApproverStats
+	}{
+		{
+			// states of the default transition table
+			states: models.ResponseStates{
+				Approved: []string{models.Approved},
+				Declined: []string{models.Declined},
+				Awaiting: []string{models.MessageSent},
+			},
+			expected: models.ApproverStats{Email: "approver@mail.com", Approved: 1, Declined: 1, Pending: 1},
+		},
+		{
+			states:   models.ResponseStates{Declined: []string{models.Approved, models.Declined}},
+			expected: models.ApproverStats{Email: "approver@mail.com", Declined: 2},
+		},
+	}
+	for _, tt := range tests {
+		stats, err := db.ApproverStats(context.TODO(), "", tt.states)
+		if err != nil {
+			t.Fatalf("unexpected error on getting approver statistics: %v", err)
+		}
+		if len(stats) != 1 || stats[0].Email != tt.expected.Email || stats[0].Approved != tt.expected.Approved ||
+			stats[0].Declined != tt.expected.Declined || stats[0].Pending != tt.expected.Pending {
+			t.Fatalf("%+v: expected %+v, got %+v", tt.states, tt.expected, stats)
+		}
+		if stats[0].AvgLag != 15*time.Second {
+			t.Fatalf("%+v: expected average lag 15s, got %v", tt.states, stats[0].AvgLag)
+		}
+	}
+}
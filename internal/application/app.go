@@ -2,21 +2,35 @@ package application
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/seggga/approve-analytics/internal/adapters/auth"
+	"github.com/seggga/approve-analytics/internal/adapters/grpcquery"
+	"github.com/seggga/approve-analytics/internal/adapters/msglistener/goodrpc"
 	kfk "github.com/seggga/approve-analytics/internal/adapters/msglistener/kafkaconsumer"
 	"github.com/seggga/approve-analytics/internal/adapters/rest"
+	"github.com/seggga/approve-analytics/internal/adapters/storage/memory"
 	"github.com/seggga/approve-analytics/internal/adapters/storage/postgres"
+	"github.com/seggga/approve-analytics/internal/adapters/storage/sqlite"
 	"github.com/seggga/approve-analytics/internal/domain/analytic"
+	"github.com/seggga/approve-analytics/internal/domain/deadletter"
+	"github.com/seggga/approve-analytics/internal/domain/sla"
+	"github.com/seggga/approve-analytics/internal/ports"
 	"golang.org/x/sync/errgroup"
 
 	"go.uber.org/zap"
 )
 
 var (
-	restService *rest.Server
-	msgListener *kfk.Client
-	authClient  *auth.Client
+	analyticService *analytic.Service
+	restService     *rest.Server
+	msgListener     *kfk.Client
+	grpcListener    *goodrpc.Server
+	queryService    *grpcquery.Server
+	authClient      *auth.Client
+	dlqWriter       *kfk.DLQWriter
+	publisher       *kfk.Publisher
 
 	logger *zap.Logger
 )
@@ -26,46 +40,212 @@ func Start(ctx context.Context) {
 
 	var err error
 	cfg := getConfig()
-	logger := initLogger(cfg.Logger.Level)
+	logger = initLogger(cfg.Logger.Level)
 
-	pgConn, err := postgres.New(cfg.Postgres.DSN)
+	store, err := openStorage(cfg)
 	if err != nil {
-		logger.Sugar().Fatalf("cannot connect to postgre: %v", err)
-	}
-
-	err = pgConn.Init(context.TODO())
-	if err != nil {
-		logger.Sugar().Fatalf("cannot init postgre schema: %v", err)
+		logger.Sugar().Fatalf("cannot open storage: %v", err)
 	}
 
 	authClient, err = auth.NewClient(cfg.IFaces.AUTHAddress)
 	if err != nil {
 		logger.Sugar().Fatalf("cannot create gRPC client: %v", err)
 	}
-	analyticService := analytic.New(pgConn)
-	restService = rest.New(logger, authClient, analyticService, cfg.IFaces.RESTPort)
-	// msgListener = goodrpc.New(analytic.New(pgConn), logger, cfg.IFaces.MSGPort)
-	msgListener, err = kfk.New(cfg.Kafka.Server, cfg.Kafka.Topic, cfg.Kafka.GroupID, logger, analyticService)
+	sink, err := cfg.sink()
 	if err != nil {
-		logger.Sugar().Fatalf("cannot create kafka client: %v", err)
+		logger.Sugar().Fatalf("bad dead-letter config: %v", err)
+	}
+	var dlq ports.DeadLetterSink
+	switch sink {
+	case SinkStorage:
+		// rejected messages are kept by the configured storage
+		dlq = store
+	case SinkKafka:
+		dlqWriter, err = kfk.NewDLQWriter(cfg.Kafka.connection(), cfg.DeadLetter.Topic)
+		if err != nil {
+			logger.Sugar().Fatalf("cannot create dead-letter writer: %v", err)
+		}
+		dlq = dlqWriter
+	}
+
+	table, err := analytic.NewTransitions(cfg.transitions())
+	if err != nil {
+		logger.Sugar().Fatalf("bad transition table: %v", err)
+	}
+	opts := []analytic.Option{analytic.WithTransitions(table)}
+	if cfg.Reorder.Window > 0 {
+		opts = append(opts, analytic.WithReorder(cfg.Reorder.Window, cfg.Reorder.maxPerTask(), dlq))
+	}
+	if cfg.Publisher.Topic != "" {
+		publisher, err = kfk.NewPublisher(cfg.Kafka.connection(), cfg.Publisher.Topic, logger)
+		if err != nil {
+			logger.Sugar().Fatalf("cannot create analytics events publisher: %v", err)
+		}
+		opts = append(opts, analytic.WithPublisher(publisher, cfg.Publisher.ApprovalSLA))
+	}
+	analyticService = analytic.New(store, opts...)
+	if err = analyticService.RecountTotals(context.TODO()); err != nil {
+		logger.Sugar().Fatalf("cannot recount totals: %v", err)
+	}
+	for _, transport := range cfg.Ingestion.transports() {
+		switch transport {
+		case TransportKafka:
+			msgListener, err = kfk.New(kfk.Config{
+				Connection:     cfg.Kafka.connection(),
+				Topics:         cfg.Kafka.topics(),
+				GroupID:        cfg.Kafka.GroupID,
+				Workers:        cfg.Kafka.Workers,
+				QueueSize:      cfg.Kafka.QueueSize,
+				CommitInterval: cfg.Kafka.CommitInterval,
+				MinBytes:       cfg.Kafka.MinBytes,
+				MaxBytes:       cfg.Kafka.MaxBytes,
+				MaxWait:        cfg.Kafka.MaxWait,
+				StartOffset:    cfg.Kafka.StartOffset,
+			}, logger, analyticService, dlq)
+			if err != nil {
+				logger.Sugar().Fatalf("cannot create kafka client: %v", err)
+			}
+		case TransportGRPC:
+			grpcListener = goodrpc.New(analyticService, logger, cfg.IFaces.MSGPort)
+		default:
+			logger.Sugar().Fatalf("unknown ingestion transport %s", transport)
+		}
+	}
+
+	// rejected messages can be listed and replayed only if they are kept in a store
+	var deadLetters ports.DeadLetterer
+	if store, ok := dlq.(ports.DeadLetterStore); ok && msgListener != nil {
+		deadLetters = deadletter.New(store, msgListener)
+	}
+	// a nil interface disables SLA endpoints, so the service is assigned only if it exists
+	var slaMonitor ports.SLAMonitor
+	var slaService *sla.Service
+	if cfg.SLA.enabled() {
+		slaService = sla.New(store, cfg.SLA.policy(), table.Awaiting())
+		slaMonitor = slaService
+	}
+	restService = rest.New(logger, authClient, analyticService, deadLetters, slaMonitor, cfg.IFaces.RESTPort)
+	if cfg.IFaces.QueryPort != "" {
+		queryService = grpcquery.New(analyticService, authClient, logger, cfg.IFaces.QueryPort)
 	}
 
 	var g errgroup.Group
 	g.Go(func() error {
 		return restService.Start()
 	})
-	g.Go(func() error {
-		return msgListener.Start(ctx)
-	})
+	if msgListener != nil {
+		g.Go(func() error {
+			return msgListener.Start(ctx)
+		})
+	}
+	if grpcListener != nil {
+		g.Go(func() error {
+			return grpcListener.Start()
+		})
+	}
+	if queryService != nil {
+		g.Go(func() error {
+			return queryService.Start()
+		})
+	}
+	if cfg.Reorder.Window > 0 {
+		g.Go(func() error {
+			expireParked(ctx, analyticService, cfg.Reorder.Window/2)
+			return nil
+		})
+	}
+
+	if slaService != nil {
+		g.Go(func() error {
+			scanBreaches(ctx, slaService, cfg.SLA.scanInterval())
+			return nil
+		})
+	}
 
 	logger.Info("app is started")
 	err = g.Wait()
 	if err != nil {
-		logger.Sugar().Fatalf("http server start failed: %v", err)
+		logger.Sugar().Fatalf("service start failed: %v", err)
 	}
 
 }
 
+// storage keeps analytics, rejected messages and SLA breaches
+type storage interface {
+	ports.EventStorage
+	ports.DeadLetterStore
+	ports.SLAStorage
+}
+
+// openStorage connects to the storage selected by the config and brings its schema up to date
+func openStorage(cfg *Config) (storage, error) {
+	switch cfg.Storage.driver() {
+	case DriverPostgres:
+		pgConn, err := postgres.New(cfg.Postgres.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("cannot connect to postgre: %v", err)
+		}
+		if err = pgConn.Init(context.TODO()); err != nil {
+			return nil, fmt.Errorf("cannot init postgre schema: %v", err)
+		}
+		return pgConn, nil
+	case DriverSQLite:
+		store, err := sqlite.New(cfg.SQLite.Path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open sqlite database: %v", err)
+		}
+		if err = store.Init(context.TODO()); err != nil {
+			return nil, fmt.Errorf("cannot init sqlite schema: %v", err)
+		}
+		return store, nil
+	case DriverMemory:
+		logger.Warn("analytics are kept in memory and will be lost on restart")
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %s", cfg.Storage.Driver)
+	}
+}
+
+// expireParked periodically passes expired messages of the reorder buffer to the dead-letter sink
+func expireParked(ctx context.Context, an *analytic.Service, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := an.ExpireParked(ctx); err != nil {
+				logger.Sugar().Errorf("error expiring parked messages: %v", err)
+			}
+		}
+	}
+}
+
+// scanBreaches periodically records SLA breaches and logs the ones detected for the first time
+func scanBreaches(ctx context.Context, s *sla.Service, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			breaches, err := s.Scan(ctx)
+			if err != nil {
+				logger.Sugar().Errorf("error scanning SLA breaches: %v", err)
+				continue
+			}
+			for _, b := range breaches {
+				logger.Sugar().Warnf("SLA breach: task %d is waiting for %s for %v, limit is %v",
+					b.TaskID, b.Approver, b.Waited.Round(time.Second), b.Limit)
+			}
+		}
+	}
+}
+
 // Stop ...
 func Stop() {
 	defer logger.Sync()
@@ -73,17 +253,48 @@ func Stop() {
 	// stop REST
 	err := restService.Stop(context.Background())
 	if err != nil {
-		logger.Sugar().Errorf("error stopping REST service", err)
+		logger.Sugar().Errorf("error stopping REST service: %v", err)
+	}
+	// stop gRPC query service
+	if queryService != nil {
+		queryService.Stop()
 	}
 	// stop gRPC authentication client
 	err = authClient.Conn.Close()
 	if err != nil {
-		logger.Sugar().Errorf("error stopping auth client service", err)
+		logger.Sugar().Errorf("error stopping auth client service: %v", err)
 	}
 	// stop kafka consumer
-	err = msgListener.Stop()
-	if err != nil {
-		logger.Sugar().Errorf("error stopping kafka listener service", err)
+	if msgListener != nil {
+		err = msgListener.Stop()
+		if err != nil {
+			logger.Sugar().Errorf("error stopping kafka listener service: %v", err)
+		}
+	}
+	// stop gRPC message listener
+	if grpcListener != nil {
+		grpcListener.Stop()
+	}
+	// the reorder buffer is lost on stop, so parked messages are kept as dead letters
+	if analyticService != nil {
+		err = analyticService.FlushParked(context.Background())
+		if err != nil {
+			logger.Sugar().Errorf("error flushing parked messages: %v", err)
+		}
+	}
+	// stop analytics events publisher
+	if publisher != nil {
+		err = publisher.Close()
+		if err != nil {
+			logger.Sugar().Errorf("error stopping analytics events publisher: %v", err)
+		}
+	}
+	// stop dead-letter writer
+	if dlqWriter != nil {
+		err = dlqWriter.Close()
+		if err != nil {
+			logger.Sugar().Errorf("error stopping dead-letter writer: %v", err)
+		}
 	}
 
 	logger.Sugar().Info("application has been stopped")
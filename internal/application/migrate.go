@@ -0,0 +1,64 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/seggga/approve-analytics/internal/adapters/storage/postgres"
+)
+
+// Migrate manages the database schema according to args:
+//
+// up 		- applies all pending migrations
+// down [N] - rolls back N last migrations, 1 by default
+// version 	- reports the current schema version
+//
+// Migrations apply to postgres only, other storages create their schema on start
+func Migrate(ctx context.Context, args []string) error {
+	cfg := getConfig()
+	logger = initLogger(cfg.Logger.Level)
+	defer logger.Sync()
+
+	if len(args) == 0 {
+		return fmt.Errorf("migrate command is missed, expected up, down [N] or version")
+	}
+
+	if driver := cfg.Storage.driver(); driver != DriverPostgres {
+		return fmt.Errorf("migrations apply to postgres only, %s storage creates its schema on start", driver)
+	}
+
+	store, err := postgres.New(cfg.Postgres.DSN)
+	if err != nil {
+		return fmt.Errorf("cannot connect to postgre: %v", err)
+	}
+	defer store.Pool.Close()
+
+	switch args[0] {
+	case "up":
+		err = store.MigrateUp(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("bad number of migrations to roll back %q", args[1])
+			}
+		}
+		err = store.MigrateDown(ctx, steps)
+	case "version":
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down [N] or version", args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("migration failed: %v", err)
+	}
+
+	version, err := store.Version(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Sugar().Infof("database schema version is %d", version)
+
+	return nil
+}
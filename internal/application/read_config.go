@@ -2,19 +2,58 @@ package application
 
 import (
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"time"
 
+	kfk "github.com/seggga/approve-analytics/internal/adapters/msglistener/kafkaconsumer"
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+	"github.com/seggga/approve-analytics/internal/domain/models"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents configuration for the application
 type Config struct {
-	Postgres Postgres `yaml:"postgres"`
-	IFaces   IFaces   `yaml:"ifaces"`
-	Logger   Logger   `yaml:"logger"`
-	Kafka    Kafka    `yaml:"kafka"`
+	Storage     Storage      `yaml:"storage"`
+	Postgres    Postgres     `yaml:"postgres"`
+	SQLite      SQLite       `yaml:"sqlite"`
+	IFaces      IFaces       `yaml:"ifaces"`
+	Logger      Logger       `yaml:"logger"`
+	Kafka       Kafka        `yaml:"kafka"`
+	DeadLetter  DeadLetter   `yaml:"dead_letter"`
+	Ingestion   Ingestion    `yaml:"ingestion"`
+	Reorder     Reorder      `yaml:"reorder"`
+	Publisher   Publisher    `yaml:"publisher"`
+	SLA         SLA          `yaml:"sla"`
+	Transitions []Transition `yaml:"transitions"`
+}
+
+// storage drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Storage selects the database keeping analytics, postgres by default.
+// The memory driver loses data on restart, it is meant for local demos
+type Storage struct {
+	Driver string `yaml:"driver"`
+}
+
+// SQLite keeps the path of the database file, it is created if it does not exist
+type SQLite struct {
+	Path string `yaml:"path"`
+}
+
+// driver returns the configured driver or the default one
+func (s Storage) driver() string {
+	if s.Driver == "" {
+		return DriverPostgres
+	}
+	return s.Driver
 }
 
 // Postgres represents configuration data for establishing connection
@@ -22,10 +61,11 @@ type Postgres struct {
 	DSN string `yaml:"connection-string"`
 }
 
-// IFaces contains ports on services
+// IFaces contains ports on services, empty QueryPort disables gRPC query service
 type IFaces struct {
 	RESTPort    string `yaml:"rest_port"`
 	MSGPort     string `yaml:"msg_listener_port"`
+	QueryPort   string `yaml:"query_port"`
 	AUTHAddress string `yaml:"auth_server_address"`
 }
 
@@ -34,16 +74,221 @@ type Logger struct {
 	Level string `yaml:"level"`
 }
 
-// Kafka keeps values to connect
+// Kafka keeps values to connect and to process messages concurrently,
+// zero values are replaced by defaults of the consumer. Server is a single broker
+// kept for older configs, it is used if Brokers is empty. Topic is a single JSON topic
+// used if Topics is empty
 type Kafka struct {
-	Server  string `yaml:"server"`
-	Topic   string `yaml:"topic"`
-	GroupID string `yaml:"group_id"`
+	Server         string        `yaml:"server"`
+	Brokers        []string      `yaml:"brokers"`
+	TLS            KafkaTLS      `yaml:"tls"`
+	SASL           KafkaSASL     `yaml:"sasl"`
+	Topic          string        `yaml:"topic"`
+	Topics         []KafkaTopic  `yaml:"topics"`
+	GroupID        string        `yaml:"group_id"`
+	Workers        int           `yaml:"workers"`
+	QueueSize      int           `yaml:"queue_size"`
+	CommitInterval time.Duration `yaml:"commit_interval"`
+	MinBytes       int           `yaml:"min_bytes"`
+	MaxBytes       int           `yaml:"max_bytes"`
+	MaxWait        time.Duration `yaml:"max_wait"`
+	StartOffset    string        `yaml:"start_offset"`
+}
+
+// KafkaTopic sets the format of messages in the topic: json (by default), protobuf or cloudevents
+type KafkaTopic struct {
+	Name   string `yaml:"name"`
+	Format string `yaml:"format"`
+}
+
+// topics returns consumed topics
+func (k Kafka) topics() []kfk.Topic {
+	if len(k.Topics) == 0 {
+		return []kfk.Topic{{Name: k.Topic, Format: kfk.FormatJSON}}
+	}
+
+	topics := make([]kfk.Topic, 0, len(k.Topics))
+	for _, t := range k.Topics {
+		topics = append(topics, kfk.Topic(t))
+	}
+	return topics
+}
+
+// KafkaTLS enables TLS, files are PEM encoded
+type KafkaTLS struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// KafkaSASL enables authentication: plain, scram-sha-256 or scram-sha-512
+type KafkaSASL struct {
+	Mechanism string `yaml:"mechanism"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+}
+
+// connection returns brokers and security parameters of the consumer
+func (k Kafka) connection() kfk.Connection {
+	brokers := k.Brokers
+	if len(brokers) == 0 && k.Server != "" {
+		brokers = []string{k.Server}
+	}
+
+	return kfk.Connection{
+		Brokers: brokers,
+		TLS:     kfk.TLS(k.TLS),
+		SASL:    kfk.SASL(k.SASL),
+	}
+}
+
+// dead-letter sinks
+const (
+	SinkStorage = "storage"
+	SinkKafka   = "kafka"
+
+	// sinkPostgres is the former name of SinkStorage, it is accepted with the postgres driver only
+	sinkPostgres = "postgres"
+)
+
+// DeadLetter chooses where rejected messages are kept: the configured storage,
+// kafka (Topic is required) or nowhere if Sink is empty
+type DeadLetter struct {
+	Sink  string `yaml:"sink"`
+	Topic string `yaml:"topic"`
+}
+
+// sink returns the configured sink, the former postgres sink is replaced by SinkStorage
+// if the storage is postgres indeed
+func (c *Config) sink() (string, error) {
+	switch c.DeadLetter.Sink {
+	case "", SinkStorage, SinkKafka:
+		return c.DeadLetter.Sink, nil
+	case sinkPostgres:
+		if c.Storage.driver() != DriverPostgres {
+			return "", fmt.Errorf("dead-letter sink %s requires the %s storage driver, use %s", sinkPostgres, DriverPostgres, SinkStorage)
+		}
+		return SinkStorage, nil
+	default:
+		return "", fmt.Errorf("unknown dead-letter sink %s", c.DeadLetter.Sink)
+	}
+}
+
+// ingestion transports
+const (
+	TransportKafka = "kafka"
+	TransportGRPC  = "grpc"
+)
+
+// Ingestion lists transports receiving messages from other services, kafka by default
+type Ingestion struct {
+	Transports []string `yaml:"transports"`
+}
+
+// transports returns configured transports or the default one
+func (i Ingestion) transports() []string {
+	if len(i.Transports) == 0 {
+		return []string{TransportKafka}
+	}
+	return i.Transports
+}
+
+// defaultMaxParked limits parked messages of a task if Reorder.MaxPerTask is not set
+const defaultMaxParked = 100
+
+// Reorder configures the buffer keeping messages arrived before their predecessors,
+// zero Window disables it
+type Reorder struct {
+	Window     time.Duration `yaml:"window"`
+	MaxPerTask int           `yaml:"max_per_task"`
+}
+
+// maxPerTask returns the configured limit or the default one
+func (r Reorder) maxPerTask() int {
+	if r.MaxPerTask <= 0 {
+		return defaultMaxParked
+	}
+	return r.MaxPerTask
 }
 
+// Publisher sets the kafka topic receiving analytics events, empty Topic disables publishing.
+// ApprovalSLA is the longest approver response not reported as a breach, zero disables breach events
+type Publisher struct {
+	Topic       string        `yaml:"topic"`
+	ApprovalSLA time.Duration `yaml:"approval_sla"`
+}
+
+// defaultScanInterval is the period of SLA breach scans if SLA.ScanInterval is not set
+const defaultScanInterval = time.Minute
+
+// SLA limits the time tasks may wait for approvers. PerApprover overrides Default for particular
+// approvers, SLA is disabled if no limits are set
+type SLA struct {
+	Default      time.Duration            `yaml:"default"`
+	PerApprover  map[string]time.Duration `yaml:"per_approver"`
+	ScanInterval time.Duration            `yaml:"scan_interval"`
+}
+
+// enabled reports whether any limit is set
+func (s SLA) enabled() bool {
+	if s.Default > 0 {
+		return true
+	}
+	for _, limit := range s.PerApprover {
+		if limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// policy returns limits of the SLA
+func (s SLA) policy() models.SLAPolicy {
+	return models.SLAPolicy{
+		Default:     s.Default,
+		PerApprover: s.PerApprover,
+	}
+}
+
+// scanInterval returns the configured period or the default one
+func (s SLA) scanInterval() time.Duration {
+	if s.ScanInterval <= 0 {
+		return defaultScanInterval
+	}
+	return s.ScanInterval
+}
+
+// Transition is a row of the task state machine, the whole table replaces the default lifecycle.
+// Empty From means a new task, Guard is either empty or same_approver,
+// Action is create, update or accumulate_delay
+type Transition struct {
+	From   string `yaml:"from"`
+	Event  string `yaml:"event"`
+	Guard  string `yaml:"guard"`
+	Action string `yaml:"action"`
+}
+
+// transitions returns the configured table or the default one
+func (c *Config) transitions() []models.Transition {
+	if len(c.Transitions) == 0 {
+		return analytic.DefaultTransitions()
+	}
+
+	transitions := make([]models.Transition, 0, len(c.Transitions))
+	for _, t := range c.Transitions {
+		transitions = append(transitions, models.Transition(t))
+	}
+	return transitions
+}
+
+var path = flag.String("c", "./configs/config.yaml", "set path to config yaml-file")
+
 func getConfig() *Config {
-	path := flag.String("c", "./configs/config.yaml", "set path to config yaml-file")
-	flag.Parse()
+	if !flag.Parsed() {
+		flag.Parse()
+	}
 
 	log.Printf("config file, %s", *path)
 
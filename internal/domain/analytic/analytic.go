@@ -3,6 +3,7 @@ package analytic
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/seggga/approve-analytics/internal/domain/models"
 	"github.com/seggga/approve-analytics/internal/ports"
@@ -14,105 +15,261 @@ var (
 
 // Service implements main analytics logic
 type Service struct {
-	db ports.EventStorage
+	db          ports.EventStorage
+	transitions *Transitions
+	reorder     *reorderBuffer
+	now         func() time.Time
+
+	pub         ports.Publisher
+	approvalSLA time.Duration
 }
 
-// New creates a new auth service
-func New(db ports.EventStorage) *Service {
-	return &Service{
-		db: db,
+// New creates a new analytics service
+func New(db ports.EventStorage, opts ...Option) *Service {
+	s := &Service{
+		db:  db,
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.transitions == nil {
+		s.transitions = defaultTransitions
 	}
+
+	return s
 }
 
-// WriteEvent receives a Message from message service.
-// Like a state-machine WriteEvent has finite number of transitions
-// for a particuar task:
-//
-// no task_id 	-> CREATED
-// CREATED 		-> MESSAGE_SENT
-// MESSAGE_SENT -> ( APPROVED || DECLINED ) && ( approver == approver )
-// APPROVED 	-> FINISHED || MESSAGE_SENT
+// WriteEvent receives a Message from message service. A message with an id that has
+// already been stored is a duplicate, it is skipped and reported as written.
+// If the reorder buffer is enabled, messages parked for the task are applied after the message.
 //
-// CREATED || MESSAGE_SENT || APPROVED -> DELETED
+// Errors wrap ErrInvalidMessage, ErrInvalidTransition, ErrApproverMismatch or ErrStorage
 func (s *Service) WriteEvent(ctx context.Context, msg *models.Message) error {
-	evt, err := s.db.Select(ctx, msg.TaskID)
-	if err != nil {
-		return fmt.Errorf("error selecting event by taskID, %v, %v", msg, err)
+	err := s.writeUnique(ctx, msg)
+	if err == nil && s.reorder != nil {
+		s.applyParked(ctx, msg.TaskID)
 	}
-	// no task_id 	-> CREATED
-	if evt == nil && msg.EventType == models.Created {
-		err = s.db.Insert(ctx, msg)
-		if err != nil {
-			return fmt.Errorf("error writing event data into storage: %v, %v", msg, err)
-		}
 
+	return err
+}
+
+// writeUnique writes the message unless it is a duplicate
+func (s *Service) writeUnique(ctx context.Context, msg *models.Message) error {
+	err := s.writeEvent(ctx, msg)
+	// a new task cannot be locked, so the same CREATED message written concurrently
+	// fails on the task key, then it is a duplicate as well
+	if err != nil && msg.ID != "" && s.seen(ctx, msg) {
 		return nil
 	}
 
-	// CREATED 	-> MESSAGE_SENT
-	if evt != nil && evt.EventType == models.Created && msg.EventType == models.MessageSent {
-		err = s.db.Update(ctx, msg)
-		if err != nil {
-			return fmt.Errorf("error updating event data in storage: %v, %v, %v", evt, msg, err)
-		}
-		return nil
+	return err
+}
+
+// seen reports whether the message is a duplicate, on storage failure the message is treated as a new one
+func (s *Service) seen(ctx context.Context, msg *models.Message) bool {
+	seen, err := s.db.Seen(ctx, msg.ID)
+	return err == nil && seen
+}
+
+// writeEvent applies the message and updates totals in a transaction. The task stays locked since it is selected,
+// so messages of the same task written concurrently are applied one by one
+// and a duplicate is recognized even if the first copy is being written right now
+func (s *Service) writeEvent(ctx context.Context, msg *models.Message) error {
+	if !s.transitions.known(msg.EventType) {
+		return fmt.Errorf("%w: unknown event type %q, %v", ErrInvalidMessage, msg.EventType, msg)
 	}
 
-	// MESSAGE_SENT -> ( APPROVED || DECLINED ) && ( approver == approver )
-	if evt != nil && evt.EventType == models.MessageSent && (msg.EventType == models.Approved || msg.EventType == models.Declined) {
-		if evt.Approver != msg.Approver {
-			return fmt.Errorf("approvers are not equal: %v %v", evt, msg)
-		}
-		err = s.db.UpdateDelay(ctx, msg)
+	var derived []models.AnalyticsEvent
+	err := s.db.Atomic(ctx, func(tx ports.EventStorage) error {
+		evt, err := tx.Select(ctx, msg.TaskID)
 		if err != nil {
-			return fmt.Errorf("error updating event with delay in storage: %v, %v, %v", evt, msg, err)
+			return fmt.Errorf("%w: error selecting event by taskID, %v, %v", ErrStorage, msg, err)
 		}
-		return nil
-	}
 
-	// APPROVED 	-> FINISHED || MESSAGE_SENT
-	if evt != nil && evt.EventType == models.Approved && (msg.EventType == models.Finished || msg.EventType == models.MessageSent) {
-		err = s.db.Update(ctx, msg)
-		if err != nil {
-			return fmt.Errorf("error updating event data in storage: %v, %v, %v", evt, msg, err)
+		if msg.ID != "" {
+			seen, err := tx.Seen(ctx, msg.ID)
+			if err != nil {
+				return fmt.Errorf("%w: error checking message id, %v, %v", ErrStorage, msg, err)
+			}
+			if seen {
+				return nil
+			}
 		}
-		return nil
-	}
 
-	// CREATED || APPROVED -> DELETED
-	// no additional delay
-	// no matter, who is approver - if Tasks service sent DELETE message,
-	// than means the sender is the task owner
-	if evt != nil && (evt.EventType == models.Created || evt.EventType == models.Approved) && msg.EventType == models.Deleted {
-		err = s.db.Update(ctx, msg)
+		tr, err := s.transitions.find(evt, msg)
 		if err != nil {
-			return fmt.Errorf("error updating event data in storage: %v, %v, %v", evt, msg, err)
+			return err
+		}
+		if err := s.transitions.apply(ctx, tx, tr, evt, msg); err != nil {
+			return err
+		}
+		if finished, declined := s.transitions.totalsDelta(evt, msg.EventType); finished != 0 || declined != 0 {
+			if err := tx.AddTotals(ctx, finished, declined); err != nil {
+				return fmt.Errorf("%w: error updating totals in storage: %v, %v", ErrStorage, msg, err)
+			}
 		}
+		derived = s.derive(tr, evt, msg)
 		return nil
+	})
+	if err != nil && !classified(err) {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if err == nil {
+		s.publish(ctx, derived)
 	}
 
-	// MESSAGE_SENT -> DELETED
-	// calculates additional delay
-	// no matter, who is approver - if Tasks service sent DELETE message,
-	// than means the sender is the task owner
-	if evt != nil && evt.EventType == models.MessageSent && msg.EventType == models.Deleted {
-		err = s.db.UpdateDelay(ctx, msg)
-		if err != nil {
-			return fmt.Errorf("error updating event with delay in storage: %v, %v, %v", evt, msg, err)
-		}
-		return nil
+	return err
+}
+
+// GetTotals reads numbers of finished and declined tasks
+func (s *Service) GetTotals(ctx context.Context) (*models.Totals, error) {
+	totals, err := s.db.GetTotals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting totals from DB, %v", ErrStorage, err)
 	}
 
-	return fmt.Errorf("due to previous found event %v, message %v has not been classified as valid", evt, msg)
+	return totals, nil
 }
 
-// GetAggregates extracts requested data
-func (s *Service) GetAggregates(ctx context.Context) (*models.Totals, []models.Delay, error) {
+// GetAggregates extracts totals and delays matching the filter, nil filter means all delays.
+// Delays are taken from tasks in final states of the transition table
+func (s *Service) GetAggregates(ctx context.Context, filter *models.DelayFilter) (*models.Totals, []models.Delay, error) {
+	var f models.DelayFilter
+	if filter != nil {
+		f = *filter
+	}
+	f.Final = s.transitions.Final()
 
-	totals, delays, err := s.db.GetAggregates(ctx)
+	totals, delays, err := s.db.GetAggregates(ctx, &f)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error getting aggregates from DB, %v", err)
+		return nil, nil, fmt.Errorf("%w: error getting aggregates from DB, %v", ErrStorage, err)
 	}
 
 	return totals, delays, nil
 }
+
+// GetDelayStats calculates statistics on delays of tasks matching the filter,
+// tasks are taken in final states of the transition table
+func (s *Service) GetDelayStats(ctx context.Context, filter *models.StatsFilter) (*models.DelayStats, error) {
+	var f models.StatsFilter
+	if filter != nil {
+		f = *filter
+	}
+	f.Final = s.transitions.Final()
+
+	stats, err := s.db.DelayStats(ctx, &f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting delay statistics from DB, %v", ErrStorage, err)
+	}
+
+	return stats, nil
+}
+
+// GetTimeSeries groups tasks in a final state of the transition table into buckets of the filter interval
+func (s *Service) GetTimeSeries(ctx context.Context, filter *models.SeriesFilter) ([]models.Point, error) {
+	f := *filter
+	f.Final = s.transitions.Final()
+
+	points, err := s.db.TimeSeries(ctx, &f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting time series from DB, %v", ErrStorage, err)
+	}
+
+	return points, nil
+}
+
+// GetTransitions returns the transition table of the task state machine
+func (s *Service) GetTransitions(ctx context.Context) []models.Transition {
+	return s.transitions.List()
+}
+
+// GetHistory extracts all events stored for the task
+func (s *Service) GetHistory(ctx context.Context, taskID uint64) ([]models.Event, error) {
+
+	events, err := s.db.History(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting task history from DB, %v", ErrStorage, err)
+	}
+
+	return events, nil
+}
+
+// GetApprovers extracts response statistics of all known approvers,
+// responses and awaiting states are taken from the transition table
+func (s *Service) GetApprovers(ctx context.Context) ([]models.ApproverStats, error) {
+
+	stats, err := s.db.ApproverStats(ctx, "", s.transitions.Responses())
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting approver statistics from DB, %v", ErrStorage, err)
+	}
+
+	return stats, nil
+}
+
+// GetApprover extracts response statistics of the approver, nil means the approver is unknown
+func (s *Service) GetApprover(ctx context.Context, email string) (*models.ApproverStats, error) {
+	if email == "" {
+		return nil, nil
+	}
+
+	stats, err := s.db.ApproverStats(ctx, email, s.transitions.Responses())
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting approver statistics from DB, %v", ErrStorage, err)
+	}
+
+	if len(stats) == 0 {
+		return nil, nil
+	}
+
+	return &stats[0], nil
+}
+
+// GetTask extracts the current state of the task, nil means the task is unknown
+func (s *Service) GetTask(ctx context.Context, taskID uint64) (*models.TaskState, error) {
+
+	evt, err := s.db.Select(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error selecting event by taskID from DB, %v", ErrStorage, err)
+	}
+
+	if evt == nil {
+		return nil, nil
+	}
+
+	return &models.TaskState{
+		TaskID:     evt.TaskID,
+		State:      evt.EventType,
+		Approver:   evt.Approver,
+		Since:      evt.RecievedAt,
+		Age:        s.now().Sub(evt.RecievedAt),
+		TotalDelay: evt.TotalDelay,
+	}, nil
+}
+
+// GetPending counts tasks in every pending state of the transition table,
+// states without tasks are reported with zero counters
+func (s *Service) GetPending(ctx context.Context) ([]models.PendingState, error) {
+	states := s.transitions.Pending()
+
+	stats, err := s.db.PendingStats(ctx, s.now(), states)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting pending tasks from DB, %v", ErrStorage, err)
+	}
+
+	byState := make(map[string]models.PendingState, len(stats))
+	for _, st := range stats {
+		byState[st.State] = st
+	}
+
+	pending := make([]models.PendingState, 0, len(states))
+	for _, state := range states {
+		st, ok := byState[state]
+		if !ok {
+			st = models.PendingState{State: state}
+		}
+		pending = append(pending, st)
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,23 @@
+package analytic
+
+import "errors"
+
+// errors returned by Service, callers check them with errors.Is
+var (
+	// ErrInvalidMessage means the message cannot be processed at all, e.g. it has unknown event type
+	ErrInvalidMessage = errors.New("invalid message")
+	// ErrInvalidTransition means the message does not fit the current state of the task
+	ErrInvalidTransition = errors.New("invalid transition")
+	// ErrApproverMismatch means the response comes from another approver than the task has been sent to
+	ErrApproverMismatch = errors.New("approver mismatch")
+	// ErrStorage means the event storage has failed, the message may be processed later
+	ErrStorage = errors.New("storage failure")
+)
+
+// classified reports whether err wraps one of the errors above
+func classified(err error) bool {
+	return errors.Is(err, ErrInvalidMessage) ||
+		errors.Is(err, ErrInvalidTransition) ||
+		errors.Is(err, ErrApproverMismatch) ||
+		errors.Is(err, ErrStorage)
+}
@@ -0,0 +1,65 @@
+package analytic
+
+import (
+	"context"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+// WithPublisher enables publishing of derived events: TaskCompleted on every terminal transition
+// and ApprovalSLABreached when an approver responds later than approvalSLA, zero approvalSLA disables the latter
+func WithPublisher(pub ports.Publisher, approvalSLA time.Duration) Option {
+	return func(s *Service) {
+		s.pub = pub
+		s.approvalSLA = approvalSLA
+	}
+}
+
+// derive makes events caused by applying the message to evt, the previous state of the task, through tr
+func (s *Service) derive(tr models.Transition, evt *models.Event, msg *models.Message) []models.AnalyticsEvent {
+	if s.pub == nil || evt == nil {
+		return nil
+	}
+
+	// a lag is added only by transitions accumulating the delay
+	var lag time.Duration
+	if tr.Action == models.ActionAccumulateDelay {
+		lag = msg.RecievedAt.Sub(evt.RecievedAt)
+	}
+
+	// the approver responds by transitions guarded by the approver check
+	var events []models.AnalyticsEvent
+	if s.approvalSLA > 0 && tr.Guard == models.GuardSameApprover && lag > s.approvalSLA {
+		events = append(events, models.AnalyticsEvent{
+			Type:       models.ApprovalSLABreached,
+			TaskID:     msg.TaskID,
+			Approver:   msg.Approver,
+			Lag:        lag,
+			SLA:        s.approvalSLA,
+			OccurredAt: msg.RecievedAt,
+		})
+	}
+
+	if s.transitions.isFinal(msg.EventType) {
+		events = append(events, models.AnalyticsEvent{
+			Type:       models.TaskCompleted,
+			TaskID:     msg.TaskID,
+			Outcome:    msg.EventType,
+			Lag:        evt.TotalDelay + lag,
+			OccurredAt: msg.RecievedAt,
+		})
+	}
+
+	return events
+}
+
+// publish sends derived events. The message is stored already, so a failed publication
+// does not fail the write, the publisher reports it
+func (s *Service) publish(ctx context.Context, events []models.AnalyticsEvent) {
+	if s.pub == nil || len(events) == 0 {
+		return
+	}
+	s.pub.Publish(ctx, events...)
+}
@@ -0,0 +1,66 @@
+package analytic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// publisher collects published events
+type publisher struct {
+	events []models.AnalyticsEvent
+}
+
+func (p *publisher) Publish(ctx context.Context, events ...models.AnalyticsEvent) {
+	p.events = append(p.events, events...)
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.TODO()
+	db := &taskStorage{tasks: make(map[uint64]models.Event)}
+	pub := &publisher{}
+	s := New(db, WithPublisher(pub, 30*time.Second))
+
+	msgs := []models.Message{
+		{EventType: models.Created, TaskID: 1, RecievedAt: timeStamp.Add(-100 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 1, Approver: "fast@mail.com", RecievedAt: timeStamp.Add(-99 * time.Second)},
+		{EventType: models.Approved, TaskID: 1, Approver: "fast@mail.com", RecievedAt: timeStamp.Add(-89 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 1, Approver: "slow@mail.com", RecievedAt: timeStamp.Add(-89 * time.Second)},
+		{EventType: models.Declined, TaskID: 1, Approver: "slow@mail.com", RecievedAt: timeStamp.Add(-39 * time.Second)},
+	}
+	for _, v := range msgs {
+		if err := s.WriteEvent(ctx, &v); err != nil {
+			t.Fatalf("unexpected error on message %v: %v", v, err)
+		}
+	}
+
+	expected := []models.AnalyticsEvent{
+		{
+			Type:       models.ApprovalSLABreached,
+			TaskID:     1,
+			Approver:   "slow@mail.com",
+			Lag:        50 * time.Second,
+			SLA:        30 * time.Second,
+			OccurredAt: msgs[4].RecievedAt,
+		},
+		{
+			Type:       models.TaskCompleted,
+			TaskID:     1,
+			Outcome:    models.Declined,
+			Lag:        60 * time.Second,
+			OccurredAt: msgs[4].RecievedAt,
+		},
+	}
+	if !reflect.DeepEqual(pub.events, expected) {
+		t.Fatalf("wrong events, expected %v, got %v", expected, pub.events)
+	}
+
+	// a rejected message publishes nothing
+	msg := models.Message{EventType: models.Finished, TaskID: 1, RecievedAt: timeStamp}
+	if err := s.WriteEvent(ctx, &msg); err == nil || len(pub.events) != len(expected) {
+		t.Fatalf("rejected message has published events: %v, %v", pub.events, err)
+	}
+}
@@ -0,0 +1,259 @@
+package analytic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+// reorderTopic marks dead letters expired in the reorder buffer, they have no position in kafka
+const reorderTopic = "reorder-buffer"
+
+// Option configures the Service
+type Option func(*Service)
+
+// WithReorder enables the reorder buffer. A message arrived before its predecessor can be parked
+// by Park for the window and applied once the missing transition arrives. At most maxPerTask messages
+// are parked for a task, the next ones are not taken. Expired messages are passed
+// to sink, nil sink means they are dropped
+func WithReorder(window time.Duration, maxPerTask int, sink ports.DeadLetterSink) Option {
+	return func(s *Service) {
+		s.reorder = &reorderBuffer{
+			window:     window,
+			maxPerTask: maxPerTask,
+			sink:       sink,
+			now:        time.Now,
+			parked:     make(map[uint64][]parkedMessage),
+			ids:        make(map[string]struct{}),
+		}
+	}
+}
+
+// reorderBuffer keeps out-of-sequence messages per task. ids indexes messages that are parked
+// or taken out to be written or rejected, they are settled once the result is known
+type reorderBuffer struct {
+	window     time.Duration
+	maxPerTask int
+	sink       ports.DeadLetterSink
+	now        func() time.Time
+
+	mu     sync.Mutex
+	parked map[uint64][]parkedMessage
+	ids    map[string]struct{}
+}
+
+// parkedMessage is a message waiting for its predecessor, reason is the last failure
+type parkedMessage struct {
+	msg      models.Message
+	reason   error
+	parkedAt time.Time
+}
+
+// park keeps the message, the result is false if the task has no room left.
+// A message with an id that is already parked is not kept twice
+func (b *reorderBuffer) park(msg *models.Message, reason error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.ids[msg.ID]; ok && msg.ID != "" {
+		return true
+	}
+	if len(b.parked[msg.TaskID]) >= b.maxPerTask {
+		return false
+	}
+
+	b.put(msg.TaskID, parkedMessage{msg: *msg, reason: reason, parkedAt: b.now()})
+	return true
+}
+
+// put adds messages to the task keeping them in the order they have been sent, the caller holds the lock
+func (b *reorderBuffer) put(taskID uint64, msgs ...parkedMessage) {
+	if len(msgs) == 0 {
+		return
+	}
+
+	for _, p := range msgs {
+		if p.msg.ID != "" {
+			b.ids[p.msg.ID] = struct{}{}
+		}
+	}
+
+	parked := append(b.parked[taskID], msgs...)
+	sort.SliceStable(parked, func(i, j int) bool {
+		return parked[i].msg.RecievedAt.Before(parked[j].msg.RecievedAt)
+	})
+	b.parked[taskID] = parked
+}
+
+// take removes all messages parked for the task, they stay indexed till they are settled
+func (b *reorderBuffer) take(taskID uint64) []parkedMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	parked := b.parked[taskID]
+	delete(b.parked, taskID)
+	return parked
+}
+
+// settle returns taken messages that are still waiting and forgets the ones that are written or rejected
+func (b *reorderBuffer) settle(taskID uint64, waiting, done []parkedMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, p := range done {
+		delete(b.ids, p.msg.ID)
+	}
+	b.put(taskID, waiting...)
+}
+
+// reject passes the message to the dead-letter sink
+func (b *reorderBuffer) reject(ctx context.Context, p parkedMessage, reason error) error {
+	if b.sink == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(p.msg)
+	if err != nil {
+		return fmt.Errorf("error marshaling parked message %v: %v", p.msg, err)
+	}
+
+	return b.sink.Reject(ctx, &models.RejectedMessage{
+		Topic:     reorderTopic,
+		Partition: -1,
+		Offset:    -1,
+		Payload:   payload,
+		Error:     reason.Error(),
+	})
+}
+
+// applyParked writes messages parked for the task that fit its current state.
+// Passes are repeated while they write something, since a written message may unblock the next one
+func (s *Service) applyParked(ctx context.Context, taskID uint64) {
+	for {
+		parked := s.reorder.take(taskID)
+		if len(parked) == 0 {
+			return
+		}
+
+		var applied bool
+		var waiting, done []parkedMessage
+		for _, p := range parked {
+			err := s.writeUnique(ctx, &p.msg)
+			switch {
+			case err == nil:
+				applied = true
+				done = append(done, p)
+			case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrStorage):
+				p.reason = err
+				waiting = append(waiting, p)
+			default:
+				// the message can never be applied, it is kept only if the sink fails
+				if s.reorder.reject(ctx, p, err) != nil {
+					p.reason = err
+					waiting = append(waiting, p)
+					continue
+				}
+				done = append(done, p)
+			}
+		}
+		s.reorder.settle(taskID, waiting, done)
+
+		if !applied {
+			return
+		}
+	}
+}
+
+// Park keeps the message rejected with ErrInvalidTransition till its predecessor arrives.
+// It is meant for transports that acknowledge the message once it is applied or passed to the dead-letter
+// sink, see Parked. The result is false if the reorder buffer is disabled, the error is of another kind
+// or the task has no room left, then the message has to be rejected by the caller
+func (s *Service) Park(ctx context.Context, msg *models.Message, reason error) bool {
+	if s.reorder == nil || !errors.Is(reason, ErrInvalidTransition) || !s.reorder.park(msg, reason) {
+		return false
+	}
+
+	// the predecessor may have been written while the message was failing
+	s.applyParked(ctx, msg.TaskID)
+	return true
+}
+
+// Parked reports whether the message with the id is still waiting in the reorder buffer.
+// A parked message being written or rejected counts as parked till the result is known
+func (s *Service) Parked(messageID string) bool {
+	if s.reorder == nil || messageID == "" {
+		return false
+	}
+
+	b := s.reorder
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, ok := b.ids[messageID]
+	return ok
+}
+
+// ExpireParked passes messages parked longer than the reorder window to the dead-letter sink.
+// Messages the sink fails to take stay parked till the next call
+func (s *Service) ExpireParked(ctx context.Context) error {
+	if s.reorder == nil {
+		return nil
+	}
+
+	deadline := s.reorder.now().Add(-s.reorder.window)
+	return s.rejectParked(ctx, deadline, fmt.Sprintf("expired in reorder buffer after %v", s.reorder.window))
+}
+
+// FlushParked passes all parked messages to the dead-letter sink, so they are not lost on stop
+func (s *Service) FlushParked(ctx context.Context) error {
+	if s.reorder == nil {
+		return nil
+	}
+
+	return s.rejectParked(ctx, s.reorder.now(), "flushed from reorder buffer on stop")
+}
+
+// rejectParked passes messages parked not later than deadline to the dead-letter sink
+func (s *Service) rejectParked(ctx context.Context, deadline time.Time, why string) error {
+	b := s.reorder
+	b.mu.Lock()
+	var expired []parkedMessage
+	for taskID, parked := range b.parked {
+		waiting := parked[:0]
+		for _, p := range parked {
+			if p.parkedAt.After(deadline) {
+				waiting = append(waiting, p)
+				continue
+			}
+			expired = append(expired, p)
+		}
+		if len(waiting) == 0 {
+			delete(b.parked, taskID)
+			continue
+		}
+		b.parked[taskID] = waiting
+	}
+	b.mu.Unlock()
+
+	var firstErr error
+	for _, p := range expired {
+		err := b.reject(ctx, p, fmt.Errorf("%s: %v", why, p.reason))
+		if err == nil {
+			b.settle(p.msg.TaskID, nil, []parkedMessage{p})
+			continue
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("error rejecting parked message %v: %v", p.msg, err)
+		}
+		b.settle(p.msg.TaskID, []parkedMessage{p}, nil)
+	}
+
+	return firstErr
+}
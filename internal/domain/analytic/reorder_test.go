@@ -0,0 +1,265 @@
+package analytic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+// taskStorage keeps the last event of every task, methods not used by WriteEvent are left unimplemented
+type taskStorage struct {
+	ports.EventStorage
+	tasks  map[uint64]models.Event
+	totals struct{ finished, declined int64 }
+}
+
+func (s *taskStorage) Atomic(ctx context.Context, fn func(tx ports.EventStorage) error) error {
+	return fn(s)
+}
+
+func (s *taskStorage) Select(ctx context.Context, taskID uint64) (*models.Event, error) {
+	evt, ok := s.tasks[taskID]
+	if !ok {
+		return nil, nil
+	}
+	return &evt, nil
+}
+
+func (s *taskStorage) Insert(ctx context.Context, msg *models.Message) error {
+	return s.Update(ctx, msg)
+}
+
+func (s *taskStorage) Update(ctx context.Context, msg *models.Message) error {
+	s.tasks[msg.TaskID] = models.Event{
+		EventType:  msg.EventType,
+		TaskID:     msg.TaskID,
+		Approver:   msg.Approver,
+		RecievedAt: msg.RecievedAt,
+		TotalDelay: s.tasks[msg.TaskID].TotalDelay,
+	}
+	return nil
+}
+
+func (s *taskStorage) UpdateDelay(ctx context.Context, msg *models.Message) error {
+	prev := s.tasks[msg.TaskID]
+	delay := msg.RecievedAt.Sub(prev.RecievedAt)
+	s.tasks[msg.TaskID] = models.Event{
+		EventType:  msg.EventType,
+		TaskID:     msg.TaskID,
+		Approver:   msg.Approver,
+		RecievedAt: msg.RecievedAt,
+		Delay:      delay,
+		TotalDelay: prev.TotalDelay + delay,
+	}
+	return nil
+}
+
+func (s *taskStorage) AddTotals(ctx context.Context, finished, declined int64) error {
+	s.totals.finished += finished
+	s.totals.declined += declined
+	return nil
+}
+
+func (s *taskStorage) Seen(ctx context.Context, messageID string) (bool, error) {
+	return false, nil
+}
+
+// sink collects rejected messages
+type sink struct {
+	rejected []models.RejectedMessage
+}
+
+func (s *sink) Reject(ctx context.Context, msg *models.RejectedMessage) error {
+	s.rejected = append(s.rejected, *msg)
+	return nil
+}
+
+// writeOrPark writes the message the way an asynchronous transport does, parking it if it arrived too early
+func writeOrPark(ctx context.Context, s *Service, msg *models.Message) error {
+	err := s.WriteEvent(ctx, msg)
+	if err != nil && s.Park(ctx, msg, err) {
+		return nil
+	}
+	return err
+}
+
+func TestReorder(t *testing.T) {
+	ctx := context.TODO()
+	db := &taskStorage{tasks: make(map[uint64]models.Event)}
+	s := New(db, WithReorder(time.Minute, 2, &sink{}))
+
+	// FINISHED and APPROVED arrive before MESSAGE_SENT
+	msgs := []models.Message{
+		{ID: "1", EventType: models.Created, TaskID: 1, RecievedAt: timeStamp.Add(-10 * time.Second)},
+		{ID: "4", EventType: models.Finished, TaskID: 1, RecievedAt: timeStamp.Add(-7 * time.Second)},
+		{ID: "3", EventType: models.Approved, TaskID: 1, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-8 * time.Second)},
+	}
+	for _, v := range msgs {
+		if err := writeOrPark(ctx, s, &v); err != nil {
+			t.Fatalf("unexpected error on message %v: %v", v, err)
+		}
+	}
+	if db.tasks[1].EventType != models.Created || !s.Parked("3") || !s.Parked("4") {
+		t.Fatalf("out-of-sequence messages have been applied: %v", db.tasks[1])
+	}
+
+	// the buffer is full
+	msg := models.Message{ID: "5", EventType: models.Declined, TaskID: 1, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-6 * time.Second)}
+	if err := writeOrPark(ctx, s, &msg); err == nil {
+		t.Fatalf("message has been parked over the limit: %v", msg)
+	}
+
+	// synchronous writes are not parked
+	msg = models.Message{ID: "6", EventType: models.Approved, TaskID: 2, Approver: "approver@mail.com", RecievedAt: timeStamp}
+	if err := s.WriteEvent(ctx, &msg); !errors.Is(err, ErrInvalidTransition) || s.Parked("6") {
+		t.Fatalf("expected invalid transition without parking, got %v", err)
+	}
+
+	msg = models.Message{ID: "2", EventType: models.MessageSent, TaskID: 1, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-9 * time.Second)}
+	if err := s.WriteEvent(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on message %v: %v", msg, err)
+	}
+	if db.tasks[1].EventType != models.Finished || s.Parked("3") || s.Parked("4") {
+		t.Fatalf("parked messages have not been applied: %v", db.tasks[1])
+	}
+	if parked := s.reorder.take(1); len(parked) != 0 {
+		t.Fatalf("applied messages are still parked: %v", parked)
+	}
+}
+
+func TestExpireParked(t *testing.T) {
+	ctx := context.TODO()
+	db := &taskStorage{tasks: make(map[uint64]models.Event)}
+	dlq := &sink{}
+	s := New(db, WithReorder(time.Minute, 10, dlq))
+
+	now := timeStamp
+	s.reorder.now = func() time.Time { return now }
+
+	msg := models.Message{ID: "2-1", EventType: models.Approved, TaskID: 2, Approver: "approver@mail.com", RecievedAt: timeStamp}
+	if err := writeOrPark(ctx, s, &msg); err != nil {
+		t.Fatalf("unexpected error on message %v: %v", msg, err)
+	}
+
+	now = now.Add(30 * time.Second)
+	if err := s.ExpireParked(ctx); err != nil || len(dlq.rejected) != 0 {
+		t.Fatalf("message has expired before the window: %v, %v", dlq.rejected, err)
+	}
+
+	now = now.Add(30 * time.Second)
+	if err := s.ExpireParked(ctx); err != nil || len(dlq.rejected) != 1 {
+		t.Fatalf("message has not expired after the window: %v, %v", dlq.rejected, err)
+	}
+
+	var rejected models.Message
+	if err := json.Unmarshal(dlq.rejected[0].Payload, &rejected); err != nil || rejected.ID != msg.ID {
+		t.Fatalf("wrong rejected payload %s: %v", dlq.rejected[0].Payload, err)
+	}
+	if parked := s.reorder.take(2); len(parked) != 0 {
+		t.Fatalf("expired message is still parked: %v", parked)
+	}
+}
+
+func TestFlushParked(t *testing.T) {
+	ctx := context.TODO()
+	db := &taskStorage{tasks: make(map[uint64]models.Event)}
+	dlq := &sink{}
+	s := New(db, WithReorder(time.Minute, 10, dlq))
+
+	msg := models.Message{ID: "3-1", EventType: models.Finished, TaskID: 3, RecievedAt: timeStamp}
+	if err := writeOrPark(ctx, s, &msg); err != nil {
+		t.Fatalf("unexpected error on message %v: %v", msg, err)
+	}
+
+	if err := s.FlushParked(ctx); err != nil || len(dlq.rejected) != 1 || s.Parked(msg.ID) {
+		t.Fatalf("parked message has not been flushed: %v, %v", dlq.rejected, err)
+	}
+}
+
+// watchedStorage calls written on every message it writes
+type watchedStorage struct {
+	*taskStorage
+	written func(msg *models.Message)
+}
+
+func (s *watchedStorage) Atomic(ctx context.Context, fn func(tx ports.EventStorage) error) error {
+	return fn(s)
+}
+
+func (s *watchedStorage) Update(ctx context.Context, msg *models.Message) error {
+	s.written(msg)
+	return s.taskStorage.Update(ctx, msg)
+}
+
+func (s *watchedStorage) UpdateDelay(ctx context.Context, msg *models.Message) error {
+	s.written(msg)
+	return s.taskStorage.UpdateDelay(ctx, msg)
+}
+
+// watchedSink calls rejected on every message it takes
+type watchedSink struct {
+	sink
+	rejected func(msg *models.RejectedMessage)
+}
+
+func (s *watchedSink) Reject(ctx context.Context, msg *models.RejectedMessage) error {
+	s.rejected(msg)
+	return s.sink.Reject(ctx, msg)
+}
+
+func TestParkedWhileSettled(t *testing.T) {
+	ctx := context.TODO()
+	db := &watchedStorage{taskStorage: &taskStorage{tasks: make(map[uint64]models.Event)}}
+	dlq := &watchedSink{}
+	s := New(db, WithReorder(time.Minute, 10, dlq))
+
+	var written, whileWritten []string
+	db.written = func(msg *models.Message) {
+		written = append(written, msg.ID)
+		if !s.Parked(msg.ID) {
+			whileWritten = append(whileWritten, msg.ID)
+		}
+	}
+	var whileRejected []string
+	dlq.rejected = func(msg *models.RejectedMessage) {
+		var rejected models.Message
+		if err := json.Unmarshal(msg.Payload, &rejected); err == nil && !s.Parked(rejected.ID) {
+			whileRejected = append(whileRejected, rejected.ID)
+		}
+	}
+
+	msgs := []models.Message{
+		{ID: "4-2", EventType: models.MessageSent, TaskID: 4, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-9 * time.Second)},
+		{ID: "5-1", EventType: models.Finished, TaskID: 5, RecievedAt: timeStamp},
+	}
+	for _, v := range msgs {
+		if err := writeOrPark(ctx, s, &v); err != nil {
+			t.Fatalf("unexpected error on message %v: %v", v, err)
+		}
+	}
+
+	// the parked message is applied after its predecessor is inserted, it is released only after it is written
+	msg := models.Message{ID: "4-1", EventType: models.Created, TaskID: 4, RecievedAt: timeStamp.Add(-10 * time.Second)}
+	if err := s.WriteEvent(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on message %v: %v", msg, err)
+	}
+	if db.tasks[4].EventType != models.MessageSent || s.Parked("4-2") {
+		t.Fatalf("parked message has not been applied: %v", db.tasks[4])
+	}
+	if len(written) != 1 || len(whileWritten) != 0 {
+		t.Fatalf("parked messages have been released before they are written: %v", whileWritten)
+	}
+
+	// the flushed message is released only after the sink takes it
+	if err := s.FlushParked(ctx); err != nil || len(dlq.sink.rejected) != 1 || s.Parked("5-1") {
+		t.Fatalf("parked message has not been flushed: %v, %v", dlq.sink.rejected, err)
+	}
+	if len(whileRejected) != 0 {
+		t.Fatalf("parked messages have been released before they are rejected: %v", whileRejected)
+	}
+}
@@ -0,0 +1,90 @@
+package analytic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+func (s *taskStorage) PendingStats(ctx context.Context, now time.Time, states []string) ([]models.PendingState, error) {
+	byState := make(map[string]*models.PendingState)
+	total := make(map[string]time.Duration)
+	for _, evt := range s.tasks {
+		st, ok := byState[evt.EventType]
+		if !ok {
+			st = &models.PendingState{State: evt.EventType}
+			byState[evt.EventType] = st
+		}
+		age := now.Sub(evt.RecievedAt)
+		st.Count++
+		total[evt.EventType] += age
+		if age > st.MaxAge {
+			st.MaxAge = age
+		}
+	}
+
+	stats := make([]models.PendingState, 0)
+	for _, state := range states {
+		if st, ok := byState[state]; ok {
+			st.AvgAge = total[state] / time.Duration(st.Count)
+			stats = append(stats, *st)
+		}
+	}
+	return stats, nil
+}
+
+func TestTasks(t *testing.T) {
+	ctx := context.TODO()
+	db := &taskStorage{tasks: make(map[uint64]models.Event)}
+	s := New(db)
+	s.now = func() time.Time { return timeStamp }
+
+	msgs := []models.Message{
+		{EventType: models.Created, TaskID: 1, RecievedAt: timeStamp.Add(-100 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 1, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-90 * time.Second)},
+		{EventType: models.Approved, TaskID: 1, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-60 * time.Second)},
+		{EventType: models.Created, TaskID: 2, RecievedAt: timeStamp.Add(-40 * time.Second)},
+		{EventType: models.Created, TaskID: 3, RecievedAt: timeStamp.Add(-20 * time.Second)},
+	}
+	for _, v := range msgs {
+		if err := s.WriteEvent(ctx, &v); err != nil {
+			t.Fatalf("unexpected error on message %v: %v", v, err)
+		}
+	}
+
+	state, err := s.GetTask(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error getting task: %v", err)
+	}
+	expected := &models.TaskState{
+		TaskID:     1,
+		State:      models.Approved,
+		Approver:   "approver@mail.com",
+		Since:      timeStamp.Add(-60 * time.Second),
+		Age:        60 * time.Second,
+		TotalDelay: 30 * time.Second,
+	}
+	if !reflect.DeepEqual(state, expected) {
+		t.Fatalf("expected %v, got %v", expected, state)
+	}
+
+	if state, err := s.GetTask(ctx, 42); err != nil || state != nil {
+		t.Fatalf("unknown task has been found: %v, %v", state, err)
+	}
+
+	pending, err := s.GetPending(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error getting pending tasks: %v", err)
+	}
+	expectedPending := []models.PendingState{
+		{State: models.Created, Count: 2, AvgAge: 30 * time.Second, MaxAge: 40 * time.Second},
+		{State: models.MessageSent},
+		{State: models.Approved, Count: 1, AvgAge: 60 * time.Second, MaxAge: 60 * time.Second},
+	}
+	if !reflect.DeepEqual(pending, expectedPending) {
+		t.Fatalf("expected %v, got %v", expectedPending, pending)
+	}
+}
@@ -0,0 +1,55 @@
+package analytic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// RecountTotals replaces Totals counters by numbers of tasks counted in their current states.
+// Counters kept by migrations or under another transition table may count other states,
+// so they are recounted before writers start
+func (s *Service) RecountTotals(ctx context.Context) error {
+	finished, declined := s.transitions.countedStates()
+	if err := s.db.RecountTotals(ctx, finished, declined); err != nil {
+		return fmt.Errorf("%w: error recounting totals in DB, %v", ErrStorage, err)
+	}
+	return nil
+}
+
+// totalsDelta returns changes of Totals counters caused by moving a task from prev,
+// its current state (nil - a new task), to next state
+func (t *Transitions) totalsDelta(prev *models.Event, next string) (finished, declined int64) {
+	finished, declined = t.counted(next)
+	if prev != nil {
+		f, d := t.counted(prev.EventType)
+		finished, declined = finished-f, declined-d
+	}
+	return finished, declined
+}
+
+// countedStates splits final states by Totals counters tasks in them are counted by
+func (t *Transitions) countedStates() (finished, declined []string) {
+	for _, state := range t.Final() {
+		if f, _ := t.counted(state); f != 0 {
+			finished = append(finished, state)
+			continue
+		}
+		declined = append(declined, state)
+	}
+	return finished, declined
+}
+
+// counted returns Totals counters a task in the state is counted by.
+// FINISHED is the only successful outcome, a task in any other final state is declined
+func (t *Transitions) counted(state string) (finished, declined int64) {
+	switch {
+	case !t.isFinal(state):
+		return 0, 0
+	case state == models.Finished:
+		return 1, 0
+	default:
+		return 0, 1
+	}
+}
@@ -0,0 +1,72 @@
+package analytic
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+func TestTotals(t *testing.T) {
+	ctx := context.TODO()
+	db := &taskStorage{tasks: make(map[uint64]models.Event)}
+	s := New(db)
+
+	msgs := []models.Message{
+		{EventType: models.Created, TaskID: 1, RecievedAt: timeStamp.Add(-100 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 1, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-90 * time.Second)},
+		{EventType: models.Approved, TaskID: 1, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-80 * time.Second)},
+		{EventType: models.Finished, TaskID: 1, RecievedAt: timeStamp.Add(-70 * time.Second)},
+		{EventType: models.Created, TaskID: 2, RecievedAt: timeStamp.Add(-60 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 2, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-50 * time.Second)},
+		{EventType: models.Declined, TaskID: 2, Approver: "approver@mail.com", RecievedAt: timeStamp.Add(-40 * time.Second)},
+		{EventType: models.Created, TaskID: 3, RecievedAt: timeStamp.Add(-30 * time.Second)},
+		{EventType: models.Deleted, TaskID: 3, RecievedAt: timeStamp.Add(-20 * time.Second)},
+		{EventType: models.Created, TaskID: 4, RecievedAt: timeStamp.Add(-10 * time.Second)},
+	}
+	for _, v := range msgs {
+		if err := s.WriteEvent(ctx, &v); err != nil {
+			t.Fatalf("unexpected error on message %v: %v", v, err)
+		}
+	}
+	// rejected messages do not change totals
+	_ = s.WriteEvent(ctx, &models.Message{EventType: models.Finished, TaskID: 4, RecievedAt: timeStamp})
+
+	if db.totals.finished != 1 || db.totals.declined != 2 {
+		t.Fatalf("expected 1 finished and 2 declined tasks, got %+v", db.totals)
+	}
+}
+
+func TestCountedStates(t *testing.T) {
+	finished, declined := defaultTransitions.countedStates()
+	sort.Strings(declined)
+	if !reflect.DeepEqual(finished, []string{models.Finished}) || !reflect.DeepEqual(declined, []string{models.Declined, models.Deleted}) {
+		t.Fatalf("wrong counted states: finished %v, declined %v", finished, declined)
+	}
+}
+
+func TestTotalsDelta(t *testing.T) {
+	tests := []struct {
+		prev               *models.Event
+		next               string
+		finished, declined int64
+	}{
+		{prev: nil, next: models.Created},
+		{prev: &models.Event{EventType: models.Approved}, next: models.Finished, finished: 1},
+		{prev: &models.Event{EventType: models.MessageSent}, next: models.Declined, declined: 1},
+		{prev: &models.Event{EventType: models.Created}, next: models.Deleted, declined: 1},
+		// a final state is left
+		{prev: &models.Event{EventType: models.Finished}, next: models.MessageSent, finished: -1},
+		{prev: &models.Event{EventType: models.Declined}, next: models.Deleted},
+	}
+
+	for _, tt := range tests {
+		finished, declined := defaultTransitions.totalsDelta(tt.prev, tt.next)
+		if finished != tt.finished || declined != tt.declined {
+			t.Fatalf("%v -> %s: expected %d, %d, got %d, %d", tt.prev, tt.next, tt.finished, tt.declined, finished, declined)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package analytic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+// DefaultTransitions is the lifecycle of a task:
+//
+// no task_id 	-> CREATED
+// CREATED 		-> MESSAGE_SENT
+// MESSAGE_SENT -> ( APPROVED || DECLINED ) && ( approver == approver )
+// APPROVED 	-> FINISHED || MESSAGE_SENT
+//
+// CREATED || MESSAGE_SENT || APPROVED -> DELETED
+//
+// The lag is accumulated while the task waits for the approver. Whoever sends DELETED,
+// it means the sender is the task owner, so the approver is not checked
+func DefaultTransitions() []models.Transition {
+	return []models.Transition{
+		{From: "", Event: models.Created, Action: models.ActionCreate},
+		{From: models.Created, Event: models.MessageSent, Action: models.ActionUpdate},
+		{From: models.MessageSent, Event: models.Approved, Guard: models.GuardSameApprover, Action: models.ActionAccumulateDelay},
+		{From: models.MessageSent, Event: models.Declined, Guard: models.GuardSameApprover, Action: models.ActionAccumulateDelay},
+		{From: models.Approved, Event: models.Finished, Action: models.ActionUpdate},
+		{From: models.Approved, Event: models.MessageSent, Action: models.ActionUpdate},
+		{From: models.Created, Event: models.Deleted, Action: models.ActionUpdate},
+		{From: models.Approved, Event: models.Deleted, Action: models.ActionUpdate},
+		{From: models.MessageSent, Event: models.Deleted, Action: models.ActionAccumulateDelay},
+	}
+}
+
+// defaultTransitions is used unless WithTransitions is given
+var defaultTransitions = mustTransitions(DefaultTransitions())
+
+func mustTransitions(list []models.Transition) *Transitions {
+	t, err := NewTransitions(list)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// transitionKey identifies a transition, a task in a state reacts to an event in one way
+type transitionKey struct {
+	from  string
+	event string
+}
+
+// Transitions is a validated transition table of the task state machine.
+// Final, pending and awaiting states are derived from the table
+type Transitions struct {
+	list   []models.Transition
+	byKey  map[transitionKey]models.Transition
+	events map[string]struct{}
+	final  map[string]struct{}
+}
+
+// NewTransitions checks the table: every transition has an event and a known action and guard,
+// new tasks are only created and a state reacts to an event once
+func NewTransitions(list []models.Transition) (*Transitions, error) {
+	t := &Transitions{
+		list:   make([]models.Transition, 0, len(list)),
+		byKey:  make(map[transitionKey]models.Transition, len(list)),
+		events: make(map[string]struct{}),
+	}
+
+	for _, tr := range list {
+		if tr.Event == "" {
+			return nil, fmt.Errorf("transition from %q has no event", tr.From)
+		}
+		switch tr.Action {
+		case models.ActionCreate, models.ActionUpdate, models.ActionAccumulateDelay:
+		default:
+			return nil, fmt.Errorf("transition %q -> %s: unknown action %q", tr.From, tr.Event, tr.Action)
+		}
+		switch tr.Guard {
+		case "", models.GuardSameApprover:
+		default:
+			return nil, fmt.Errorf("transition %q -> %s: unknown guard %q", tr.From, tr.Event, tr.Guard)
+		}
+		if (tr.From == "") != (tr.Action == models.ActionCreate) {
+			return nil, fmt.Errorf("transition %q -> %s: tasks are created from the empty state only", tr.From, tr.Event)
+		}
+		// a new task has no approver to compare with
+		if tr.From == "" && tr.Guard != "" {
+			return nil, fmt.Errorf("transition %q -> %s: a new task cannot be guarded by %q", tr.From, tr.Event, tr.Guard)
+		}
+
+		key := transitionKey{from: tr.From, event: tr.Event}
+		if _, ok := t.byKey[key]; ok {
+			return nil, fmt.Errorf("transition %q -> %s is defined twice", tr.From, tr.Event)
+		}
+		t.byKey[key] = tr
+		t.events[tr.Event] = struct{}{}
+		t.list = append(t.list, tr)
+	}
+
+	if len(t.list) == 0 {
+		return nil, fmt.Errorf("transition table is empty")
+	}
+
+	final := t.Final()
+	t.final = make(map[string]struct{}, len(final))
+	for _, state := range final {
+		t.final[state] = struct{}{}
+	}
+
+	return t, nil
+}
+
+// WithTransitions replaces DefaultTransitions
+func WithTransitions(t *Transitions) Option {
+	return func(s *Service) {
+		s.transitions = t
+	}
+}
+
+// List returns the transitions in the order they have been given
+func (t *Transitions) List() []models.Transition {
+	return append([]models.Transition(nil), t.list...)
+}
+
+// Final returns states no transition leaves, tasks in them are counted by Totals and delays
+func (t *Transitions) Final() []string {
+	return models.FinalStates(t.list)
+}
+
+// Pending returns states tasks are still in progress in
+func (t *Transitions) Pending() []string {
+	return models.PendingStates(t.list)
+}
+
+// Awaiting returns states tasks wait for the approver in, they are watched by SLA
+func (t *Transitions) Awaiting() []string {
+	return models.AwaitingStates(t.list)
+}
+
+// Responses returns states approver statistics are counted by.
+// APPROVED is the only positive response, any other response of an approver declines the task
+func (t *Transitions) Responses() models.ResponseStates {
+	states := models.ResponseStates{Awaiting: t.Awaiting()}
+	for _, event := range models.ResponseEvents(t.list) {
+		if event == models.Approved {
+			states.Approved = append(states.Approved, event)
+			continue
+		}
+		states.Declined = append(states.Declined, event)
+	}
+	return states
+}
+
+// isFinal reports whether no transition leaves the state
+func (t *Transitions) isFinal(state string) bool {
+	_, ok := t.final[state]
+	return ok
+}
+
+// known reports whether the event is used by any transition
+func (t *Transitions) known(event string) bool {
+	_, ok := t.events[event]
+	return ok
+}
+
+// find returns the transition caused by msg in evt, the current state of the task, nil means a new task
+func (t *Transitions) find(evt *models.Event, msg *models.Message) (models.Transition, error) {
+	from := ""
+	if evt != nil {
+		from = evt.EventType
+	}
+
+	tr, ok := t.byKey[transitionKey{from: from, event: msg.EventType}]
+	if !ok {
+		return tr, fmt.Errorf("%w: due to previous found event %v, message %v has not been classified as valid", ErrInvalidTransition, evt, msg)
+	}
+
+	if tr.Guard == models.GuardSameApprover && (evt == nil || evt.Approver != msg.Approver) {
+		return tr, fmt.Errorf("%w: approvers are not equal: %v %v", ErrApproverMismatch, evt, msg)
+	}
+
+	return tr, nil
+}
+
+// apply performs the action of the transition
+func (t *Transitions) apply(ctx context.Context, db ports.EventStorage, tr models.Transition, evt *models.Event, msg *models.Message) error {
+	var err error
+	switch tr.Action {
+	case models.ActionCreate:
+		// the same task created concurrently is found by the other message
+		err = db.Insert(ctx, msg)
+		if errors.Is(err, ports.ErrTaskExists) {
+			return fmt.Errorf("%w: task has been created already: %v, %v", ErrInvalidTransition, msg, err)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: error writing event data into storage: %v, %v", ErrStorage, msg, err)
+		}
+	case models.ActionUpdate:
+		if err = db.Update(ctx, msg); err != nil {
+			return fmt.Errorf("%w: error updating event data in storage: %v, %v, %v", ErrStorage, evt, msg, err)
+		}
+	case models.ActionAccumulateDelay:
+		if err = db.UpdateDelay(ctx, msg); err != nil {
+			return fmt.Errorf("%w: error updating event with delay in storage: %v, %v, %v", ErrStorage, evt, msg, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package analytic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/adapters/storage/memory"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+func TestNewTransitions(t *testing.T) {
+	if _, err := NewTransitions(DefaultTransitions()); err != nil {
+		t.Fatalf("default transitions are not valid: %v", err)
+	}
+
+	testCases := []struct {
+		name  string
+		table []models.Transition
+	}{
+		{"empty", nil},
+		{"no event", []models.Transition{{Action: models.ActionCreate}}},
+		{"unknown action", []models.Transition{{Event: models.Created, Action: "notify"}}},
+		{"unknown guard", []models.Transition{{Event: models.Created, Guard: "owner", Action: models.ActionCreate}}},
+		{"create from a state", []models.Transition{{From: models.Created, Event: models.Created, Action: models.ActionCreate}}},
+		{"update a new task", []models.Transition{{Event: models.Created, Action: models.ActionUpdate}}},
+		{"guarded new task", []models.Transition{{Event: models.Created, Guard: models.GuardSameApprover, Action: models.ActionCreate}}},
+		{"duplicate", []models.Transition{
+			{Event: models.Created, Action: models.ActionCreate},
+			{Event: models.Created, Action: models.ActionCreate},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewTransitions(tc.table); err == nil {
+				t.Fatalf("invalid table has been accepted: %v", tc.table)
+			}
+		})
+	}
+}
+
+func TestCustomTransitions(t *testing.T) {
+	const reassigned = "REASSIGNED"
+
+	table, err := NewTransitions(append(DefaultTransitions(),
+		models.Transition{From: models.MessageSent, Event: reassigned, Action: models.ActionAccumulateDelay},
+		models.Transition{From: reassigned, Event: models.MessageSent, Action: models.ActionUpdate},
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.TODO()
+	db := &taskStorage{tasks: make(map[uint64]models.Event)}
+	s := New(db, WithTransitions(table))
+
+	msgs := []models.Message{
+		{EventType: models.Created, TaskID: 1, RecievedAt: timeStamp.Add(-100 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 1, Approver: "first@mail.com", RecievedAt: timeStamp.Add(-90 * time.Second)},
+		{EventType: reassigned, TaskID: 1, Approver: "first@mail.com", RecievedAt: timeStamp.Add(-80 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 1, Approver: "second@mail.com", RecievedAt: timeStamp.Add(-70 * time.Second)},
+		{EventType: models.Approved, TaskID: 1, Approver: "second@mail.com", RecievedAt: timeStamp.Add(-65 * time.Second)},
+	}
+	for _, v := range msgs {
+		if err := s.WriteEvent(ctx, &v); err != nil {
+			t.Fatalf("unexpected error on message %v: %v", v, err)
+		}
+	}
+	if task := db.tasks[1]; task.EventType != models.Approved || task.TotalDelay != 15*time.Second {
+		t.Fatalf("wrong task state: %v", task)
+	}
+
+	// the guard of the default table is kept
+	_ = s.WriteEvent(ctx, &models.Message{EventType: models.Created, TaskID: 2, RecievedAt: timeStamp.Add(-time.Second)})
+	_ = s.WriteEvent(ctx, &models.Message{EventType: models.MessageSent, TaskID: 2, Approver: "first@mail.com", RecievedAt: timeStamp})
+	msg := models.Message{EventType: models.Approved, TaskID: 2, Approver: "second@mail.com", RecievedAt: timeStamp}
+	if err := s.WriteEvent(ctx, &msg); !errors.Is(err, ErrApproverMismatch) {
+		t.Fatalf("expected approver mismatch, got %v", err)
+	}
+
+	// events missing in the table are not valid messages
+	msg = models.Message{EventType: "ESCALATED", TaskID: 1, RecievedAt: timeStamp}
+	if err := s.WriteEvent(ctx, &msg); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("expected invalid message, got %v", err)
+	}
+
+	if got := s.GetTransitions(ctx); len(got) != len(DefaultTransitions())+2 {
+		t.Fatalf("wrong transition table: %v", got)
+	}
+}
+
+func TestStatesFromTable(t *testing.T) {
+	const reassigned, cancelled = "REASSIGNED", "CANCELLED"
+
+	if got := defaultTransitions.Final(); !reflect.DeepEqual(got, []string{models.Declined, models.Finished, models.Deleted}) {
+		t.Fatalf("wrong final states of the default table: %v", got)
+	}
+	if got := defaultTransitions.Pending(); !reflect.DeepEqual(got, []string{models.Created, models.MessageSent, models.Approved}) {
+		t.Fatalf("wrong pending states of the default table: %v", got)
+	}
+	if got := defaultTransitions.Awaiting(); !reflect.DeepEqual(got, []string{models.MessageSent}) {
+		t.Fatalf("wrong awaiting states of the default table: %v", got)
+	}
+	responses := models.ResponseStates{Approved: []string{models.Approved}, Declined: []string{models.Declined}, Awaiting: []string{models.MessageSent}}
+	if got := defaultTransitions.Responses(); !reflect.DeepEqual(got, responses) {
+		t.Fatalf("wrong response states of the default table: %v", got)
+	}
+
+	// a reassigned task waits for the new approver and may be cancelled
+	table, err := NewTransitions(append(DefaultTransitions(),
+		models.Transition{From: models.MessageSent, Event: reassigned, Action: models.ActionAccumulateDelay},
+		models.Transition{From: reassigned, Event: models.Approved, Guard: models.GuardSameApprover, Action: models.ActionAccumulateDelay},
+		models.Transition{From: reassigned, Event: cancelled, Action: models.ActionUpdate},
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := table.Final(); !reflect.DeepEqual(got, []string{models.Declined, models.Finished, models.Deleted, cancelled}) {
+		t.Fatalf("wrong final states: %v", got)
+	}
+	if got := table.Awaiting(); !reflect.DeepEqual(got, []string{models.MessageSent, reassigned}) {
+		t.Fatalf("wrong awaiting states: %v", got)
+	}
+	responses.Awaiting = []string{models.MessageSent, reassigned}
+	if got := table.Responses(); !reflect.DeepEqual(got, responses) {
+		t.Fatalf("wrong response states: %v", got)
+	}
+
+	ctx := context.TODO()
+	s := New(memory.New(), WithTransitions(table))
+	msgs := []models.Message{
+		{EventType: models.Created, TaskID: 1, RecievedAt: timeStamp.Add(-100 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 1, Approver: "first@mail.com", RecievedAt: timeStamp.Add(-90 * time.Second)},
+		{EventType: reassigned, TaskID: 1, Approver: "second@mail.com", RecievedAt: timeStamp.Add(-80 * time.Second)},
+		{EventType: models.Created, TaskID: 2, RecievedAt: timeStamp.Add(-100 * time.Second)},
+		{EventType: models.MessageSent, TaskID: 2, Approver: "first@mail.com", RecievedAt: timeStamp.Add(-90 * time.Second)},
+		{EventType: reassigned, TaskID: 2, Approver: "second@mail.com", RecievedAt: timeStamp.Add(-70 * time.Second)},
+		{EventType: cancelled, TaskID: 2, RecievedAt: timeStamp.Add(-60 * time.Second)},
+	}
+	for _, v := range msgs {
+		if err := s.WriteEvent(ctx, &v); err != nil {
+			t.Fatalf("unexpected error on message %v: %v", v, err)
+		}
+	}
+
+	pending, err := s.GetPending(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error getting pending tasks: %v", err)
+	}
+	if len(pending) != 4 || pending[3].State != reassigned || pending[3].Count != 1 {
+		t.Fatalf("reassigned task is not pending: %v", pending)
+	}
+	approver, err := s.GetApprover(ctx, "second@mail.com")
+	if err != nil || approver == nil || approver.Pending != 1 {
+		t.Fatalf("reassigned task is not awaiting the approver: %v, %v", approver, err)
+	}
+
+	// a task in a final state other than FINISHED is declined
+	totals, delays, err := s.GetAggregates(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error getting aggregates: %v", err)
+	}
+	if *totals != (models.Totals{Declined: 1}) || !reflect.DeepEqual(delays, []models.Delay{{ID: 2, Lag: 20 * time.Second}}) {
+		t.Fatalf("cancelled task is not counted: %v, %v", *totals, delays)
+	}
+
+	stats, err := s.GetDelayStats(ctx, &models.StatsFilter{EventType: cancelled})
+	if err != nil || stats.Count != 1 {
+		t.Fatalf("cancelled task is not in delay statistics: %v, %v", stats, err)
+	}
+}
+
+// racingStorage misses the task on select as if it is being created concurrently
+type racingStorage struct {
+	*taskStorage
+}
+
+func (s racingStorage) Select(ctx context.Context, taskID uint64) (*models.Event, error) {
+	return nil, nil
+}
+
+func (s racingStorage) Insert(ctx context.Context, msg *models.Message) error {
+	if _, ok := s.tasks[msg.TaskID]; ok {
+		return fmt.Errorf("%w: task %d", ports.ErrTaskExists, msg.TaskID)
+	}
+	return s.taskStorage.Insert(ctx, msg)
+}
+
+func TestConcurrentCreate(t *testing.T) {
+	ctx := context.TODO()
+	s := New(racingStorage{&taskStorage{tasks: make(map[uint64]models.Event)}})
+
+	msg := models.Message{EventType: models.Created, TaskID: 1, RecievedAt: timeStamp}
+	if err := s.WriteEvent(ctx, &msg); err != nil {
+		t.Fatalf("unexpected error on message %v: %v", msg, err)
+	}
+	if err := s.WriteEvent(ctx, &msg); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected invalid transition on a task created concurrently, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+var (
+	_ ports.DeadLetterer = &Service{}
+
+	// ErrNotFound means there is no rejected message with requested ID
+	ErrNotFound = errors.New("rejected message not found")
+	// ErrReplayed means the rejected message has already been processed
+	ErrReplayed = errors.New("rejected message has already been replayed")
+)
+
+// Service gives access to messages rejected by listeners
+type Service struct {
+	store    ports.DeadLetterStore
+	replayer ports.MsgReplayer
+}
+
+// New creates a new dead-letter service
+func New(store ports.DeadLetterStore, replayer ports.MsgReplayer) *Service {
+	return &Service{
+		store:    store,
+		replayer: replayer,
+	}
+}
+
+// ListRejected extracts messages waiting for replay. Storage errors wrap analytic.ErrStorage
+func (s *Service) ListRejected(ctx context.Context, cursor, limit uint64) ([]models.RejectedMessage, error) {
+	messages, err := s.store.ListRejected(ctx, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting rejected messages, %v", analytic.ErrStorage, err)
+	}
+
+	return messages, nil
+}
+
+// Replay processes the rejected message once again, on success the message
+// is marked as replayed and is not listed anymore
+func (s *Service) Replay(ctx context.Context, ID uint64) error {
+	msg, err := s.store.GetRejected(ctx, ID)
+	if err != nil {
+		return fmt.Errorf("%w: error getting rejected message %d, %v", analytic.ErrStorage, ID, err)
+	}
+	if msg == nil {
+		return fmt.Errorf("%w: %d", ErrNotFound, ID)
+	}
+	if msg.ReplayedAt != nil {
+		return fmt.Errorf("%w: %d at %v", ErrReplayed, ID, *msg.ReplayedAt)
+	}
+
+	if err := s.replayer.Replay(ctx, msg); err != nil {
+		return fmt.Errorf("error replaying message %d: %w", ID, err)
+	}
+
+	if err := s.store.MarkReplayed(ctx, ID); err != nil {
+		return fmt.Errorf("%w: error marking message %d as replayed, %v", analytic.ErrStorage, ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+var errProcessing = errors.New("invalid transition")
+
+// store keeps rejected messages in a map
+type store map[uint64]*models.RejectedMessage
+
+func (s store) Reject(ctx context.Context, msg *models.RejectedMessage) error {
+	msg.ID = uint64(len(s) + 1)
+	s[msg.ID] = msg
+	return nil
+}
+
+func (s store) ListRejected(ctx context.Context, cursor, limit uint64) ([]models.RejectedMessage, error) {
+	messages := make([]models.RejectedMessage, 0)
+	for id := cursor + 1; id <= uint64(len(s)) && uint64(len(messages)) < limit; id++ {
+		if s[id].ReplayedAt == nil {
+			messages = append(messages, *s[id])
+		}
+	}
+	return messages, nil
+}
+
+func (s store) GetRejected(ctx context.Context, ID uint64) (*models.RejectedMessage, error) {
+	return s[ID], nil
+}
+
+func (s store) MarkReplayed(ctx context.Context, ID uint64) error {
+	now := time.Now()
+	s[ID].ReplayedAt = &now
+	return nil
+}
+
+// replayer accepts payloads equal to "ok"
+type replayer struct{}
+
+func (replayer) Replay(ctx context.Context, msg *models.RejectedMessage) error {
+	if string(msg.Payload) != "ok" {
+		return errProcessing
+	}
+	return nil
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.TODO()
+	st := store{}
+	_ = st.Reject(ctx, &models.RejectedMessage{Payload: []byte("ok")})
+	_ = st.Reject(ctx, &models.RejectedMessage{Payload: []byte("bad")})
+
+	s := New(st, replayer{})
+
+	tests := []struct {
+		name    string
+		ID      uint64
+		wantErr error
+	}{
+		{name: "replayed", ID: 1},
+		{name: "replayed twice", ID: 1, wantErr: ErrReplayed},
+		{name: "rejected again", ID: 2, wantErr: errProcessing},
+		{name: "not found", ID: 3, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Replay(ctx, tt.ID)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	messages, err := s.ListRejected(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("unexpected error on listing: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != 2 {
+		t.Fatalf("expected only message 2 to wait for replay, got %v", messages)
+	}
+}
+
+// brokenStore fails to read rejected messages
+type brokenStore struct {
+	store
+}
+
+func (brokenStore) ListRejected(ctx context.Context, cursor, limit uint64) ([]models.RejectedMessage, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (brokenStore) GetRejected(ctx context.Context, ID uint64) (*models.RejectedMessage, error) {
+	return nil, errors.New("connection refused")
+}
+
+// storage failures are reported like the ones of analytics, so APIs map them to the same status
+func TestStorageErrors(t *testing.T) {
+	s := New(brokenStore{}, replayer{})
+
+	if _, err := s.ListRejected(context.TODO(), 0, 10); !errors.Is(err, analytic.ErrStorage) {
+		t.Fatalf("expected storage error on listing, got %v", err)
+	}
+	if err := s.Replay(context.TODO(), 1); !errors.Is(err, analytic.ErrStorage) {
+		t.Fatalf("expected storage error on replay, got %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Totals represents cumulative statistics on tasks
 type Totals struct {
@@ -14,3 +17,76 @@ type Delay struct {
 	Lag time.Duration `json:"lag"`
 	// Lag uint64
 }
+
+// DelayFilter narrows down delays of finished and declined tasks.
+// Final lists final states of the transition table, the analytics service fills it.
+// From and To bound the time a task has reached its final state, zero values are not applied.
+// Cursor is the last task ID of the previous page (0 - from the beginning),
+// Limit is the page size (0 - no limit), Desc reverses the order of task IDs
+type DelayFilter struct {
+	Final  []string
+	From   time.Time
+	To     time.Time
+	Cursor uint64
+	Limit  uint64
+	Desc   bool
+}
+
+// page sizes of delays requested through APIs
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Validate sets DefaultLimit if no limit is given and checks the limit and the period,
+// APIs call it to accept the same filters
+func (f *DelayFilter) Validate() error {
+	if f.Limit == 0 {
+		f.Limit = DefaultLimit
+	}
+	if f.Limit > MaxLimit {
+		return fmt.Errorf("limit %d is greater than %d", f.Limit, MaxLimit)
+	}
+
+	return CheckPeriod(f.From, f.To)
+}
+
+// CheckPeriod checks that from is before to, zero values are not checked
+func CheckPeriod(from, to time.Time) error {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return fmt.Errorf("from %v must be before to %v", from, to)
+	}
+	return nil
+}
+
+// DelayStats represents statistics on delays of finished and declined tasks
+type DelayStats struct {
+	Count     uint64        `json:"count"`
+	Min       time.Duration `json:"min"`
+	Max       time.Duration `json:"max"`
+	Mean      time.Duration `json:"mean"`
+	Median    time.Duration `json:"median"`
+	P90       time.Duration `json:"p90"`
+	P95       time.Duration `json:"p95"`
+	P99       time.Duration `json:"p99"`
+	Histogram []Bucket      `json:"histogram"`
+}
+
+// Bucket is a histogram bar counting delays from From to To, the last bucket includes To
+type Bucket struct {
+	From  time.Duration `json:"from"`
+	To    time.Duration `json:"to"`
+	Count uint64        `json:"count"`
+}
+
+// StatsFilter narrows down delays taken into DelayStats.
+// Final lists final states of the transition table, the analytics service fills it.
+// EventType is one of them (empty - any final state), From and To bound
+// the time a task has reached the state, Buckets is a number of histogram buckets
+type StatsFilter struct {
+	Final     []string
+	EventType string
+	From      time.Time
+	To        time.Time
+	Buckets   uint64
+}
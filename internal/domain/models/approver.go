@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ApproverStats represents statistics on responses of a particular approver.
+// Lags are measured from the state awaiting the approver to the response,
+// Pending is a number of tasks waiting for the approver's response
+type ApproverStats struct {
+	Email     string        `json:"email"`
+	Approved  uint64        `json:"approved"`
+	Declined  uint64        `json:"declined"`
+	Pending   uint64        `json:"pending"`
+	AvgLag    time.Duration `json:"avglag"`
+	MedianLag time.Duration `json:"medianlag"`
+	P95Lag    time.Duration `json:"p95lag"`
+}
+
+// ResponseStates lists states of the transition table approver statistics are counted by,
+// the analytics service fills it. Approved and Declined are responses of approvers,
+// tasks wait for a response in Awaiting states
+type ResponseStates struct {
+	Approved []string
+	Declined []string
+	Awaiting []string
+}
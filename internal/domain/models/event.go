@@ -12,12 +12,14 @@ const (
 	Deleted     string = "DELETED"
 )
 
-// Event represents a struct to store in database
+// Event represents a struct to store in database.
+// Delay is a lag caused by the event, TotalDelay is a lag accumulated by the task
 type Event struct {
-	ID         uint64
-	EventType  string
-	TaskID     uint64
-	Approver   string
-	RecievedAt time.Time
-	Delay      time.Duration
+	ID         uint64        `json:"id"`
+	EventType  string        `json:"eventtype"`
+	TaskID     uint64        `json:"taskid"`
+	Approver   string        `json:"approver"`
+	RecievedAt time.Time     `json:"recievedat"`
+	Delay      time.Duration `json:"delay"`
+	TotalDelay time.Duration `json:"totaldelay"`
 }
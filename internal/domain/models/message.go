@@ -2,8 +2,10 @@ package models
 
 import "time"
 
-// Message represents incoming message from Task and Mail services
+// Message represents incoming message from Task and Mail services.
+// ID is optional, messages with the same ID are processed once
 type Message struct {
+	ID         string    `json:"id,omitempty"`
 	EventType  string    `json:"eventtype"`
 	TaskID     uint64    `json:"taskid"`
 	Approver   string    `json:"approver"`
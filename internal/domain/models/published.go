@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// types of events published by analytics
+const (
+	TaskCompleted       string = "TASK_COMPLETED"
+	ApprovalSLABreached string = "APPROVAL_SLA_BREACHED"
+)
+
+// AnalyticsEvent is derived from incoming messages and published for other services.
+// Outcome is the final state of a completed task. Lag is the total lag of a completed task
+// or the response lag of the approver breaching SLA
+type AnalyticsEvent struct {
+	Type       string        `json:"type"`
+	TaskID     uint64        `json:"taskid"`
+	Outcome    string        `json:"outcome,omitempty"`
+	Approver   string        `json:"approver,omitempty"`
+	Lag        time.Duration `json:"lag"`
+	SLA        time.Duration `json:"sla,omitempty"`
+	OccurredAt time.Time     `json:"occurredat"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RejectedMessage represents a raw message that a listener failed to parse or process.
+// Topic, Partition and Offset point to the message in the source topic
+type RejectedMessage struct {
+	ID         uint64     `json:"id"`
+	Topic      string     `json:"topic"`
+	Partition  int        `json:"partition"`
+	Offset     int64      `json:"offset"`
+	Payload    []byte     `json:"payload"`
+	Error      string     `json:"error"`
+	RejectedAt time.Time  `json:"rejectedat"`
+	ReplayedAt *time.Time `json:"replayedat,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// SLAPolicy limits the time a task may wait for an approver. PerApprover overrides Default
+// for particular approvers, zero limit means no limit
+type SLAPolicy struct {
+	Default     time.Duration
+	PerApprover map[string]time.Duration
+}
+
+// Limit returns the limit applied to the approver
+func (p SLAPolicy) Limit(approver string) time.Duration {
+	if limit, ok := p.PerApprover[approver]; ok {
+		return limit
+	}
+	return p.Default
+}
+
+// Breach is a task waiting for the approver longer than allowed.
+// SentAt is the time the task has been sent to the approver, Waited is counted till the detection
+type Breach struct {
+	TaskID     uint64        `json:"taskid"`
+	Approver   string        `json:"approver"`
+	SentAt     time.Time     `json:"sentat"`
+	Waited     time.Duration `json:"waited"`
+	Limit      time.Duration `json:"limit"`
+	DetectedAt time.Time     `json:"detectedat"`
+}
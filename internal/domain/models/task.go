@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TaskState represents the current state of a task, that is its last event.
+// Age is the time spent in the state, TotalDelay is the lag accumulated by the task
+type TaskState struct {
+	TaskID     uint64        `json:"taskid"`
+	State      string        `json:"state"`
+	Approver   string        `json:"approver"`
+	Since      time.Time     `json:"since"`
+	Age        time.Duration `json:"age"`
+	TotalDelay time.Duration `json:"totaldelay"`
+}
+
+// PendingState represents tasks staying in a pending state of the transition table,
+// ages are measured from the last event of every task
+type PendingState struct {
+	State  string        `json:"state"`
+	Count  uint64        `json:"count"`
+	AvgAge time.Duration `json:"avgage"`
+	MaxAge time.Duration `json:"maxage"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// intervals of time series buckets
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// metrics of time series.
+// MetricDeclined counts tasks in every final state but FINISHED like Totals does
+const (
+	MetricFinished = "finished"
+	MetricDeclined = "declined"
+	MetricAvgLag   = "avg_lag"
+)
+
+// SeriesFilter describes a time series of tasks reached a final state. Tasks are put into
+// Interval buckets by the time of the final state, bucket bounds are calculated in Location.
+// Final lists final states of the transition table, the analytics service fills it.
+// From and To bound the time the final state is reached, zero values are not applied
+type SeriesFilter struct {
+	Final    []string
+	Interval string
+	Metric   string
+	Location *time.Location
+	From     time.Time
+	To       time.Time
+}
+
+// Point is a value of a time series bucket starting at Start.
+// Value is a number of tasks or an average lag in nanoseconds depending on the metric
+type Point struct {
+	Start time.Time `json:"start"`
+	Value int64     `json:"value"`
+}
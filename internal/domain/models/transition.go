@@ -0,0 +1,89 @@
+package models
+
+// actions of transitions
+const (
+	// ActionCreate stores the first event of a new task
+	ActionCreate = "create"
+	// ActionUpdate moves the task to the event keeping the accumulated delay
+	ActionUpdate = "update"
+	// ActionAccumulateDelay moves the task to the event adding the time passed since the previous one
+	ActionAccumulateDelay = "accumulate_delay"
+)
+
+// guards of transitions
+const (
+	// GuardSameApprover allows the transition only if the message comes from the approver
+	// the task has been sent to
+	GuardSameApprover = "same_approver"
+)
+
+// Transition allows an event to move a task from the From state to the state named after the event.
+// Empty From means a task that has not been stored yet, empty Guard means no conditions
+type Transition struct {
+	From   string `json:"from"`
+	Event  string `json:"event"`
+	Guard  string `json:"guard,omitempty"`
+	Action string `json:"action"`
+}
+
+// FinalStates returns states no transition leaves, tasks in them are finished or declined.
+// States are ordered as they appear in transitions
+func FinalStates(transitions []Transition) []string {
+	from := make(map[string]struct{}, len(transitions))
+	for _, tr := range transitions {
+		from[tr.From] = struct{}{}
+	}
+
+	states := make([]string, 0)
+	for _, tr := range transitions {
+		if _, ok := from[tr.Event]; !ok && !contains(states, tr.Event) {
+			states = append(states, tr.Event)
+		}
+	}
+	return states
+}
+
+// PendingStates returns states tasks are still in progress in, that is the ones transitions leave.
+// States are ordered as they appear in transitions
+func PendingStates(transitions []Transition) []string {
+	states := make([]string, 0)
+	for _, tr := range transitions {
+		if tr.From != "" && !contains(states, tr.From) {
+			states = append(states, tr.From)
+		}
+	}
+	return states
+}
+
+// AwaitingStates returns states tasks wait for the approver in, that is the ones left
+// by transitions guarded by GuardSameApprover. States are ordered as they appear in transitions
+func AwaitingStates(transitions []Transition) []string {
+	states := make([]string, 0)
+	for _, tr := range transitions {
+		if tr.Guard == GuardSameApprover && !contains(states, tr.From) {
+			states = append(states, tr.From)
+		}
+	}
+	return states
+}
+
+// ResponseEvents returns events approvers respond with, that is the ones of transitions guarded
+// by GuardSameApprover. Events are ordered as they appear in transitions
+func ResponseEvents(transitions []Transition) []string {
+	events := make([]string, 0)
+	for _, tr := range transitions {
+		if tr.Guard == GuardSameApprover && !contains(events, tr.Event) {
+			events = append(events, tr.Event)
+		}
+	}
+	return events
+}
+
+func contains(states []string, state string) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package sla
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/analytic"
+	"github.com/seggga/approve-analytics/internal/domain/models"
+	"github.com/seggga/approve-analytics/internal/ports"
+)
+
+var _ ports.SLAMonitor = &Service{}
+
+// Service detects tasks waiting for approvers longer than the policy allows
+type Service struct {
+	db       ports.SLAStorage
+	policy   models.SLAPolicy
+	awaiting []string
+	now      func() time.Time
+}
+
+// New creates a new SLA service watching tasks in awaiting states, see analytic.Transitions.Awaiting
+func New(db ports.SLAStorage, policy models.SLAPolicy, awaiting []string) *Service {
+	return &Service{
+		db:       db,
+		policy:   policy,
+		awaiting: awaiting,
+		now:      time.Now,
+	}
+}
+
+// Breaches returns tasks overdue at the moment, the longest waiting go first.
+// Storage errors wrap analytic.ErrStorage
+func (s *Service) Breaches(ctx context.Context) ([]models.Breach, error) {
+	now := s.now()
+
+	minLimit, ok := s.minLimit()
+	if !ok {
+		return []models.Breach{}, nil
+	}
+
+	// tasks sent later than that cannot be overdue under any limit
+	pending, err := s.db.PendingApprovals(ctx, now.Add(-minLimit), s.awaiting)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error getting pending approvals from DB, %v", analytic.ErrStorage, err)
+	}
+
+	breaches := make([]models.Breach, 0)
+	for _, evt := range pending {
+		limit := s.policy.Limit(evt.Approver)
+		waited := now.Sub(evt.RecievedAt)
+		if limit <= 0 || waited <= limit {
+			continue
+		}
+		breaches = append(breaches, models.Breach{
+			TaskID:     evt.TaskID,
+			Approver:   evt.Approver,
+			SentAt:     evt.RecievedAt,
+			Waited:     waited,
+			Limit:      limit,
+			DetectedAt: now,
+		})
+	}
+	sort.SliceStable(breaches, func(i, j int) bool {
+		return breaches[i].SentAt.Before(breaches[j].SentAt)
+	})
+
+	return breaches, nil
+}
+
+// Scan records current breaches, the result holds breaches detected for the first time
+func (s *Service) Scan(ctx context.Context) ([]models.Breach, error) {
+	breaches, err := s.Breaches(ctx)
+	if err != nil || len(breaches) == 0 {
+		return nil, err
+	}
+
+	recorded, err := s.db.RecordBreaches(ctx, breaches)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error recording breaches in DB, %v", analytic.ErrStorage, err)
+	}
+	return recorded, nil
+}
+
+// minLimit returns the smallest positive limit of the policy, false means there are no limits
+func (s *Service) minLimit() (time.Duration, bool) {
+	var min time.Duration
+	for _, limit := range append([]time.Duration{s.policy.Default}, values(s.policy.PerApprover)...) {
+		if limit > 0 && (min == 0 || limit < min) {
+			min = limit
+		}
+	}
+	return min, min > 0
+}
+
+func values(m map[string]time.Duration) []time.Duration {
+	res := make([]time.Duration, 0, len(m))
+	for _, v := range m {
+		res = append(res, v)
+	}
+	return res
+}
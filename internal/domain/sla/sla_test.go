@@ -0,0 +1,117 @@
+package sla
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// slaStorage keeps pending events and recorded breaches in memory
+type slaStorage struct {
+	pending  []models.Event
+	recorded map[uint64]time.Time
+}
+
+func (s *slaStorage) PendingApprovals(ctx context.Context, sentBefore time.Time, states []string) ([]models.Event, error) {
+	res := make([]models.Event, 0)
+	for _, evt := range s.pending {
+		for _, state := range states {
+			if evt.EventType == state && evt.RecievedAt.Before(sentBefore) {
+				res = append(res, evt)
+			}
+		}
+	}
+	return res, nil
+}
+
+func (s *slaStorage) RecordBreaches(ctx context.Context, breaches []models.Breach) ([]models.Breach, error) {
+	res := make([]models.Breach, 0)
+	for _, b := range breaches {
+		if sentAt, ok := s.recorded[b.TaskID]; ok && sentAt.Equal(b.SentAt) {
+			continue
+		}
+		s.recorded[b.TaskID] = b.SentAt
+		res = append(res, b)
+	}
+	return res, nil
+}
+
+func TestBreaches(t *testing.T) {
+	now := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	db := &slaStorage{
+		pending: []models.Event{
+			{TaskID: 1, EventType: models.MessageSent, Approver: "slow@mail.com", RecievedAt: now.Add(-3 * time.Hour)},
+			{TaskID: 2, EventType: models.MessageSent, Approver: "fast@mail.com", RecievedAt: now.Add(-3 * time.Hour)},
+			{TaskID: 3, EventType: models.MessageSent, Approver: "slow@mail.com", RecievedAt: now.Add(-30 * time.Minute)},
+			{TaskID: 4, EventType: models.MessageSent, Approver: "boss@mail.com", RecievedAt: now.Add(-48 * time.Hour)},
+			{TaskID: 5, EventType: models.MessageSent, Approver: "fast@mail.com", RecievedAt: now.Add(-90 * time.Minute)},
+		},
+		recorded: make(map[uint64]time.Time),
+	}
+	s := New(db, models.SLAPolicy{
+		Default: 4 * time.Hour,
+		PerApprover: map[string]time.Duration{
+			"slow@mail.com": 2 * time.Hour,
+			"fast@mail.com": time.Hour,
+			"boss@mail.com": 0,
+		},
+	}, []string{models.MessageSent})
+	s.now = func() time.Time { return now }
+
+	breaches, err := s.Breaches(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []models.Breach{
+		{TaskID: 1, Approver: "slow@mail.com", SentAt: now.Add(-3 * time.Hour), Waited: 3 * time.Hour, Limit: 2 * time.Hour, DetectedAt: now},
+		{TaskID: 2, Approver: "fast@mail.com", SentAt: now.Add(-3 * time.Hour), Waited: 3 * time.Hour, Limit: time.Hour, DetectedAt: now},
+		{TaskID: 5, Approver: "fast@mail.com", SentAt: now.Add(-90 * time.Minute), Waited: 90 * time.Minute, Limit: time.Hour, DetectedAt: now},
+	}
+	if !reflect.DeepEqual(breaches, expected) {
+		t.Fatalf("expected %v, got %v", expected, breaches)
+	}
+
+	recorded, err := s.Scan(context.TODO())
+	if err != nil || len(recorded) != 3 {
+		t.Fatalf("breaches have not been recorded: %v, %v", recorded, err)
+	}
+
+	// task 1 is still overdue, task 6 is a new breach
+	db.pending = append(db.pending, models.Event{TaskID: 6, EventType: models.MessageSent, Approver: "unknown@mail.com", RecievedAt: now.Add(-5 * time.Hour)})
+	recorded, err = s.Scan(context.TODO())
+	if err != nil || len(recorded) != 1 || recorded[0].TaskID != 6 || recorded[0].Limit != 4*time.Hour {
+		t.Fatalf("expected a breach of task 6 only, got %v, %v", recorded, err)
+	}
+}
+
+func TestBreachesNoLimits(t *testing.T) {
+	db := &slaStorage{
+		pending: []models.Event{{TaskID: 1, EventType: models.MessageSent, RecievedAt: time.Now().Add(-time.Hour)}},
+	}
+	breaches, err := New(db, models.SLAPolicy{}, []string{models.MessageSent}).Breaches(context.TODO())
+	if err != nil || len(breaches) != 0 {
+		t.Fatalf("no breaches expected without limits, got %v, %v", breaches, err)
+	}
+}
+
+// tasks wait for approvers in the states the service is given, others are not overdue
+func TestBreachesAwaitingStates(t *testing.T) {
+	now := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	db := &slaStorage{
+		pending: []models.Event{
+			{TaskID: 1, EventType: models.MessageSent, Approver: "approver@mail.com", RecievedAt: now.Add(-3 * time.Hour)},
+			{TaskID: 2, EventType: "REASSIGNED", Approver: "deputy@mail.com", RecievedAt: now.Add(-2 * time.Hour)},
+			{TaskID: 3, EventType: models.Approved, Approver: "approver@mail.com", RecievedAt: now.Add(-3 * time.Hour)},
+		},
+	}
+	s := New(db, models.SLAPolicy{Default: time.Hour}, []string{models.MessageSent, "REASSIGNED"})
+	s.now = func() time.Time { return now }
+
+	breaches, err := s.Breaches(context.TODO())
+	if err != nil || len(breaches) != 2 || breaches[0].TaskID != 1 || breaches[1].TaskID != 2 {
+		t.Fatalf("expected breaches of tasks 1 and 2, got %v, %v", breaches, err)
+	}
+}
@@ -9,7 +9,18 @@ import (
 // Analyter ...
 type Analyter interface {
 	WriteEvent(ctx context.Context, msg *models.Message) error
-	GetAggregates(ctx context.Context) (*models.Totals, []models.Delay, error)
+	Park(ctx context.Context, msg *models.Message, reason error) bool
+	Parked(messageID string) bool
+	GetTotals(ctx context.Context) (*models.Totals, error)
+	GetAggregates(ctx context.Context, filter *models.DelayFilter) (*models.Totals, []models.Delay, error)
+	GetDelayStats(ctx context.Context, filter *models.StatsFilter) (*models.DelayStats, error)
+	GetHistory(ctx context.Context, taskID uint64) ([]models.Event, error)
+	GetApprovers(ctx context.Context) ([]models.ApproverStats, error)
+	GetApprover(ctx context.Context, email string) (*models.ApproverStats, error)
+	GetTimeSeries(ctx context.Context, filter *models.SeriesFilter) ([]models.Point, error)
+	GetTask(ctx context.Context, taskID uint64) (*models.TaskState, error)
+	GetPending(ctx context.Context) ([]models.PendingState, error)
+	GetTransitions(ctx context.Context) []models.Transition
 
 	// Authenticate(ctx context.Context, tokens *models.TokenPair) (*models.TokenPair, error)
 }
@@ -0,0 +1,31 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// DeadLetterSink keeps messages rejected by a message listener
+type DeadLetterSink interface {
+	Reject(ctx context.Context, msg *models.RejectedMessage) error
+}
+
+// DeadLetterStore is a DeadLetterSink able to give rejected messages back
+type DeadLetterStore interface {
+	DeadLetterSink
+	ListRejected(ctx context.Context, cursor, limit uint64) ([]models.RejectedMessage, error)
+	GetRejected(ctx context.Context, ID uint64) (*models.RejectedMessage, error)
+	MarkReplayed(ctx context.Context, ID uint64) error
+}
+
+// MsgReplayer processes a rejected message once again
+type MsgReplayer interface {
+	Replay(ctx context.Context, msg *models.RejectedMessage) error
+}
+
+// DeadLetterer lists and replays rejected messages
+type DeadLetterer interface {
+	ListRejected(ctx context.Context, cursor, limit uint64) ([]models.RejectedMessage, error)
+	Replay(ctx context.Context, ID uint64) error
+}
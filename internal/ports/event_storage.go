@@ -2,16 +2,40 @@ package ports
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/seggga/approve-analytics/internal/domain/models"
 )
 
+// ErrTaskExists is returned by Insert if the task has been stored already
+var ErrTaskExists = errors.New("task already exists")
+
 // EventStorage ...
 type EventStorage interface {
+	// Atomic runs fn in a transaction, a task selected through tx stays locked till fn returns
+	Atomic(ctx context.Context, fn func(tx EventStorage) error) error
+
+	// Insert stores the first event of a new task, it fails with ErrTaskExists if the task is stored already
 	Insert(ctx context.Context, msg *models.Message) error
-	Select(ctx context.Context, ID uint64) (*models.Message, error)
+	Select(ctx context.Context, ID uint64) (*models.Event, error)
 	Update(ctx context.Context, msg *models.Message) error
 	UpdateDelay(ctx context.Context, msg *models.Message) error
+	History(ctx context.Context, taskID uint64) ([]models.Event, error)
+	Seen(ctx context.Context, messageID string) (bool, error)
+	// AddTotals changes counters of tasks in a final state, deltas may be negative
+	AddTotals(ctx context.Context, finished, declined int64) error
+	// RecountTotals replaces counters by numbers of tasks in finished and in declined states
+	RecountTotals(ctx context.Context, finished, declined []string) error
 
-	GetAggregates(ctx context.Context) (*models.Totals, []models.Delay, error)
+	// GetTotals reads counters of tasks in a final state
+	GetTotals(ctx context.Context) (*models.Totals, error)
+	GetAggregates(ctx context.Context, filter *models.DelayFilter) (*models.Totals, []models.Delay, error)
+	DelayStats(ctx context.Context, filter *models.StatsFilter) (*models.DelayStats, error)
+	// ApproverStats counts responses and tasks awaiting them by approver, empty email means all approvers
+	ApproverStats(ctx context.Context, email string, states models.ResponseStates) ([]models.ApproverStats, error)
+	// TimeSeries groups tasks in a final state into buckets, buckets without tasks are omitted
+	TimeSeries(ctx context.Context, filter *models.SeriesFilter) ([]models.Point, error)
+	// PendingStats counts tasks in every one of states, ages are measured till now
+	PendingStats(ctx context.Context, now time.Time, states []string) ([]models.PendingState, error)
 }
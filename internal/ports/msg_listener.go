@@ -11,6 +11,8 @@ import (
 // MsgListener2 receives messages from other services via gRPC
 type MsgListener2 interface {
 	WriteMessage(ctx context.Context, r *pb.WriteMessageRequest) (*empty.Empty, error)
+	WriteMessages(ctx context.Context, r *pb.WriteMessagesRequest) (*pb.WriteMessagesResponse, error)
+	StreamMessages(stream pb.AnalyticAPI_StreamMessagesServer) error
 }
 
 // MsgListener a universal interface for message listener
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// Publisher sends analytics events to other services. Events are sent asynchronously,
+// failures are reported by the publisher itself
+type Publisher interface {
+	Publish(ctx context.Context, events ...models.AnalyticsEvent)
+}
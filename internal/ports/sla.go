@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/seggga/approve-analytics/internal/domain/models"
+)
+
+// SLAStorage gives access to tasks waiting for approvers and keeps detected breaches
+type SLAStorage interface {
+	// PendingApprovals returns last events of tasks waiting in one of states since before sentBefore
+	PendingApprovals(ctx context.Context, sentBefore time.Time, states []string) ([]models.Event, error)
+	// RecordBreaches stores breaches, the result holds the ones that have not been stored before
+	RecordBreaches(ctx context.Context, breaches []models.Breach) ([]models.Breach, error)
+}
+
+// SLAMonitor detects tasks waiting for approvers longer than allowed
+type SLAMonitor interface {
+	Breaches(ctx context.Context) ([]models.Breach, error)
+}
@@ -0,0 +1,178 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.6.1
+// source: proto/query-v1.proto
+
+package analytics
+
+import (
+	context "context"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AnalyticsQueryClient is the client API for AnalyticsQuery service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AnalyticsQueryClient interface {
+	GetTotals(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*TotalsResponse, error)
+	GetDelays(ctx context.Context, in *DelaysRequest, opts ...grpc.CallOption) (*DelaysResponse, error)
+	GetTaskHistory(ctx context.Context, in *TaskHistoryRequest, opts ...grpc.CallOption) (*TaskHistoryResponse, error)
+}
+
+type analyticsQueryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalyticsQueryClient(cc grpc.ClientConnInterface) AnalyticsQueryClient {
+	return &analyticsQueryClient{cc}
+}
+
+func (c *analyticsQueryClient) GetTotals(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*TotalsResponse, error) {
+	out := new(TotalsResponse)
+	err := c.cc.Invoke(ctx, "/analytics.v1.AnalyticsQuery/GetTotals", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsQueryClient) GetDelays(ctx context.Context, in *DelaysRequest, opts ...grpc.CallOption) (*DelaysResponse, error) {
+	out := new(DelaysResponse)
+	err := c.cc.Invoke(ctx, "/analytics.v1.AnalyticsQuery/GetDelays", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsQueryClient) GetTaskHistory(ctx context.Context, in *TaskHistoryRequest, opts ...grpc.CallOption) (*TaskHistoryResponse, error) {
+	out := new(TaskHistoryResponse)
+	err := c.cc.Invoke(ctx, "/analytics.v1.AnalyticsQuery/GetTaskHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AnalyticsQueryServer is the server API for AnalyticsQuery service.
+// All implementations must embed UnimplementedAnalyticsQueryServer
+// for forward compatibility
+type AnalyticsQueryServer interface {
+	GetTotals(context.Context, *empty.Empty) (*TotalsResponse, error)
+	GetDelays(context.Context, *DelaysRequest) (*DelaysResponse, error)
+	GetTaskHistory(context.Context, *TaskHistoryRequest) (*TaskHistoryResponse, error)
+	mustEmbedUnimplementedAnalyticsQueryServer()
+}
+
+// UnimplementedAnalyticsQueryServer must be embedded to have forward compatible implementations.
+type UnimplementedAnalyticsQueryServer struct {
+}
+
+func (UnimplementedAnalyticsQueryServer) GetTotals(context.Context, *empty.Empty) (*TotalsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTotals not implemented")
+}
+func (UnimplementedAnalyticsQueryServer) GetDelays(context.Context, *DelaysRequest) (*DelaysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDelays not implemented")
+}
+func (UnimplementedAnalyticsQueryServer) GetTaskHistory(context.Context, *TaskHistoryRequest) (*TaskHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTaskHistory not implemented")
+}
+func (UnimplementedAnalyticsQueryServer) mustEmbedUnimplementedAnalyticsQueryServer() {}
+
+// UnsafeAnalyticsQueryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalyticsQueryServer will
+// result in compilation errors.
+type UnsafeAnalyticsQueryServer interface {
+	mustEmbedUnimplementedAnalyticsQueryServer()
+}
+
+func RegisterAnalyticsQueryServer(s grpc.ServiceRegistrar, srv AnalyticsQueryServer) {
+	s.RegisterService(&AnalyticsQuery_ServiceDesc, srv)
+}
+
+func _AnalyticsQuery_GetTotals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsQueryServer).GetTotals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/analytics.v1.AnalyticsQuery/GetTotals",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsQueryServer).GetTotals(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsQuery_GetDelays_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DelaysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsQueryServer).GetDelays(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/analytics.v1.AnalyticsQuery/GetDelays",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsQueryServer).GetDelays(ctx, req.(*DelaysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsQuery_GetTaskHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsQueryServer).GetTaskHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/analytics.v1.AnalyticsQuery/GetTaskHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsQueryServer).GetTaskHistory(ctx, req.(*TaskHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AnalyticsQuery_ServiceDesc is the grpc.ServiceDesc for AnalyticsQuery service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnalyticsQuery_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "analytics.v1.AnalyticsQuery",
+	HandlerType: (*AnalyticsQueryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetTotals",
+			Handler:    _AnalyticsQuery_GetTotals_Handler,
+		},
+		{
+			MethodName: "GetDelays",
+			Handler:    _AnalyticsQuery_GetDelays_Handler,
+		},
+		{
+			MethodName: "GetTaskHistory",
+			Handler:    _AnalyticsQuery_GetTaskHistory_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/query-v1.proto",
+}
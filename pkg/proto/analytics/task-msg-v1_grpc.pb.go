@@ -24,6 +24,10 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AnalyticAPIClient interface {
 	WriteMessage(ctx context.Context, in *WriteMessageRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// WriteMessages processes messages one by one in the given order
+	WriteMessages(ctx context.Context, in *WriteMessagesRequest, opts ...grpc.CallOption) (*WriteMessagesResponse, error)
+	// StreamMessages processes messages in the order they are sent, results are returned when the stream is closed
+	StreamMessages(ctx context.Context, opts ...grpc.CallOption) (AnalyticAPI_StreamMessagesClient, error)
 }
 
 type analyticAPIClient struct {
@@ -43,11 +47,58 @@ func (c *analyticAPIClient) WriteMessage(ctx context.Context, in *WriteMessageRe
 	return out, nil
 }
 
+func (c *analyticAPIClient) WriteMessages(ctx context.Context, in *WriteMessagesRequest, opts ...grpc.CallOption) (*WriteMessagesResponse, error) {
+	out := new(WriteMessagesResponse)
+	err := c.cc.Invoke(ctx, "/analytics.v1.AnalyticAPI/WriteMessages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticAPIClient) StreamMessages(ctx context.Context, opts ...grpc.CallOption) (AnalyticAPI_StreamMessagesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AnalyticAPI_ServiceDesc.Streams[0], "/analytics.v1.AnalyticAPI/StreamMessages", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyticAPIStreamMessagesClient{stream}
+	return x, nil
+}
+
+type AnalyticAPI_StreamMessagesClient interface {
+	Send(*WriteMessageRequest) error
+	CloseAndRecv() (*WriteMessagesResponse, error)
+	grpc.ClientStream
+}
+
+type analyticAPIStreamMessagesClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyticAPIStreamMessagesClient) Send(m *WriteMessageRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *analyticAPIStreamMessagesClient) CloseAndRecv() (*WriteMessagesResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(WriteMessagesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // AnalyticAPIServer is the server API for AnalyticAPI service.
 // All implementations must embed UnimplementedAnalyticAPIServer
 // for forward compatibility
 type AnalyticAPIServer interface {
 	WriteMessage(context.Context, *WriteMessageRequest) (*empty.Empty, error)
+	// WriteMessages processes messages one by one in the given order
+	WriteMessages(context.Context, *WriteMessagesRequest) (*WriteMessagesResponse, error)
+	// StreamMessages processes messages in the order they are sent, results are returned when the stream is closed
+	StreamMessages(AnalyticAPI_StreamMessagesServer) error
 	mustEmbedUnimplementedAnalyticAPIServer()
 }
 
@@ -58,6 +109,12 @@ type UnimplementedAnalyticAPIServer struct {
 func (UnimplementedAnalyticAPIServer) WriteMessage(context.Context, *WriteMessageRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method WriteMessage not implemented")
 }
+func (UnimplementedAnalyticAPIServer) WriteMessages(context.Context, *WriteMessagesRequest) (*WriteMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WriteMessages not implemented")
+}
+func (UnimplementedAnalyticAPIServer) StreamMessages(AnalyticAPI_StreamMessagesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamMessages not implemented")
+}
 func (UnimplementedAnalyticAPIServer) mustEmbedUnimplementedAnalyticAPIServer() {}
 
 // UnsafeAnalyticAPIServer may be embedded to opt out of forward compatibility for this service.
@@ -89,6 +146,50 @@ func _AnalyticAPI_WriteMessage_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AnalyticAPI_WriteMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticAPIServer).WriteMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/analytics.v1.AnalyticAPI/WriteMessages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticAPIServer).WriteMessages(ctx, req.(*WriteMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticAPI_StreamMessages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AnalyticAPIServer).StreamMessages(&analyticAPIStreamMessagesServer{stream})
+}
+
+type AnalyticAPI_StreamMessagesServer interface {
+	SendAndClose(*WriteMessagesResponse) error
+	Recv() (*WriteMessageRequest, error)
+	grpc.ServerStream
+}
+
+type analyticAPIStreamMessagesServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyticAPIStreamMessagesServer) SendAndClose(m *WriteMessagesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *analyticAPIStreamMessagesServer) Recv() (*WriteMessageRequest, error) {
+	m := new(WriteMessageRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // AnalyticAPI_ServiceDesc is the grpc.ServiceDesc for AnalyticAPI service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -100,7 +201,17 @@ var AnalyticAPI_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "WriteMessage",
 			Handler:    _AnalyticAPI_WriteMessage_Handler,
 		},
+		{
+			MethodName: "WriteMessages",
+			Handler:    _AnalyticAPI_WriteMessages_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamMessages",
+			Handler:       _AnalyticAPI_StreamMessages_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/task-msg-v1.proto",
 }